@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+
+	"kafka-producer-consumer-tester/config"
+	"kafka-producer-consumer-tester/internal/app/capacity"
+	"kafka-producer-consumer-tester/internal/app/verifier"
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+	"kafka-producer-consumer-tester/internal/pkg/load"
+	"kafka-producer-consumer-tester/internal/pkg/metrics"
+	"kafka-producer-consumer-tester/internal/pkg/tracing"
+)
+
+// searchCapacity verifies at increasing constant rates, each sustained for the
+// window as a soak run, logging the capacity found and writing it to the
+// report.
+func searchCapacity(ctx context.Context, cfg *config.Config, cdc codec.Codec, m *metrics.Metrics, t *tracing.Tracing, logger Logger) error {
+	res := capacity.Search(ctx, capacity.CapacityConfig{
+		Start:     cfg.CapacityStart,
+		Precision: cfg.CapacityPrecision,
+		Probes:    cfg.CapacityProbes,
+		Window:    cfg.CapacityWindow,
+		MaxP99:    cfg.CapacityMaxP99,
+		MaxLag:    cfg.CapacityMaxLag,
+	}, func(ctx context.Context, rate float64) (*verifier.Report, error) {
+		run := *cfg
+		run.ReportPath = ""
+		run.LoadShape, run.LoadRate, run.LoadByteRate = load.Constant, rate, 0
+		run.SoakDuration = cfg.CapacityWindow
+
+		return execute(ctx, cmdVerify, &run, cdc, m, t, logger)
+	}, logger)
+
+	for _, line := range capacity.Lines(res) {
+		logger.Info(line)
+	}
+
+	return capacity.WriteJSON(cfg.ReportPath, res)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"kafka-producer-consumer-tester/config"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	cmdProduce  = "produce"
+	cmdConsume  = "consume"
+	cmdVerify   = "verify"
+	cmdInspect  = "inspect"
+	cmdCanary   = "canary"
+	cmdReplay   = "replay"
+	cmdCapacity = "capacity"
+)
+
+var commands = []struct{ name, help string }{
+	{cmdProduce, "only write events and save a manifest of what was sent"},
+	{cmdConsume, "only read events and record what was seen"},
+	{cmdVerify, "produce and consume, verifying nothing is lost (default)"},
+	{cmdInspect, "tail a topic, printing the records matching the filters"},
+	{cmdCanary, "keep probing every partition, exposing SLO metrics"},
+	{cmdReplay, "re-read a topic, checking the events of a manifest are still there"},
+	{cmdCapacity, "search the highest rate sustained within latency and lag bounds"},
+}
+
+// command splits the subcommand from its arguments, falling back to the
+// configured mode when none is given.
+func command(args []string, mode string) (string, []string, error) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		mode, args = args[0], args[1:]
+	}
+
+	for _, c := range commands {
+		if c.name == mode {
+			return mode, args, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("unknown command %q", mode)
+}
+
+// flagSet registers the flags of the command on cfg, using the values read
+// from the environment as defaults so that flags override them.
+func flagSet(cmd string, cfg *config.Config) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.Usage = func() { usage(fs) }
+
+	fs.StringVar(&cfg.Seeds, "seeds", cfg.Seeds, "seed broker address")
+	fs.StringVar(&cfg.Topic, "topic", cfg.Topic, "topic events are produced to and consumed from")
+	fs.BoolVar(&cfg.PlainLogs, "plain", cfg.PlainLogs, "log to stderr instead of the terminal UI")
+	fs.StringVar(&cfg.ReportPath, "report", cfg.ReportPath, "file the JSON report is written to")
+	fs.StringVar(&cfg.MetricsAddr, "metrics-addr", cfg.MetricsAddr, "listen address of the /metrics endpoint")
+	fs.StringVar(&cfg.Codec, "codec", cfg.Codec, "event encoding: json, avro, protobuf or msgpack")
+	fs.StringVar(&cfg.RegistryURL, "registry", cfg.RegistryURL, "schema registry URL, or fake for an in-process one")
+	fs.Var((*list)(&cfg.EvolutionWriters), "writers", "comma separated schema versions written in turn, e.g. v1,v2,v3")
+	fs.Var((*list)(&cfg.EvolutionReaders), "readers", "comma separated schema versions every value is read with, all when empty")
+
+	if cmd == cmdProduce || cmd == cmdVerify || cmd == cmdCapacity {
+		fs.IntVar(&cfg.Batches, "batches", cfg.Batches, "number of batches produced")
+		fs.IntVar(&cfg.BatchSize, "batch-size", cfg.BatchSize, "number of events per batch")
+		fs.StringVar(&cfg.LoadShape, "shape", cfg.LoadShape, "load shape: constant, ramp, step, spike or sine")
+		fs.Float64Var(&cfg.LoadRate, "rate", cfg.LoadRate, "peak records per second, unlimited when 0")
+		fs.Float64Var(&cfg.LoadByteRate, "byte-rate", cfg.LoadByteRate, "peak bytes per second, unlimited when 0")
+		fs.DurationVar(&cfg.SoakDuration, "duration", cfg.SoakDuration, "produce for this long instead of a number of batches")
+		fs.StringVar(&cfg.ManifestPath, "manifest", cfg.ManifestPath, "file the events sent are listed in")
+		fs.IntVar(&cfg.PayloadSize, "size", cfg.PayloadSize, "fixed payload size, or mean of the normal distribution, in bytes")
+		fs.IntVar(&cfg.PayloadSizeMin, "size-min", cfg.PayloadSizeMin, "smallest payload size of the uniform and normal distributions")
+		fs.IntVar(&cfg.PayloadSizeMax, "size-max", cfg.PayloadSizeMax, "largest payload size of the uniform and normal distributions")
+		fs.IntVar(&cfg.PayloadSizeStdDev, "size-stddev", cfg.PayloadSizeStdDev, "standard deviation of the normal distribution")
+		fs.StringVar(&cfg.PayloadSizeWeights, "size-weights", cfg.PayloadSizeWeights, "size:weight pairs of the weighted distribution, e.g. 100:80,10000:15,2000000:5")
+		fs.StringVar(&cfg.Compression, "compression", cfg.Compression, "compression: none, gzip, snappy, lz4 or zstd")
+		fs.IntVar(&cfg.CompressionLevel, "compression-level", cfg.CompressionLevel, "level of the gzip (-2 to 9) and zstd (1 to 4) codecs, their default when 0")
+		fs.IntVar(&cfg.ProducerBatchMaxBytes, "batch-max-bytes", cfg.ProducerBatchMaxBytes, "largest batch, and so record, produced")
+		fs.DurationVar(&cfg.ProducerLinger, "linger", cfg.ProducerLinger, "how long a batch waits for more records before it is sent")
+		fs.StringVar(&cfg.ProducerAcks, "acks", cfg.ProducerAcks, "acknowledgements waited for: all, leader or none")
+		fs.Func("partitions", "partitions the topic is created with unless it exists", func(s string) error {
+			p, err := strconv.ParseInt(s, 10, 32)
+			cfg.TopicPartitions = int32(p)
+			return err
+		})
+		fs.StringVar(&cfg.Partitioner, "partitioner", cfg.Partitioner, "partitioner: default, sticky, round-robin, murmur2, least-backup or manual")
+		fs.Func("partition", "partition every record is sent to with the manual partitioner", func(s string) error {
+			p, err := strconv.ParseInt(s, 10, 32)
+			cfg.PartitionerPartition = int32(p)
+			return err
+		})
+	}
+
+	if cmd == cmdProduce || cmd == cmdConsume || cmd == cmdVerify || cmd == cmdCapacity {
+		fs.StringVar(&cfg.ManifestFormat, "format", cfg.ManifestFormat, "manifest format: ndjson or binary")
+		fs.StringVar(&cfg.KeyStrategy, "keys", cfg.KeyStrategy, "record keys: none, id, hot or random")
+		fs.IntVar(&cfg.HotKeys, "hot-keys", cfg.HotKeys, "number of keys of the hot strategy")
+		fs.StringVar(&cfg.Checksum, "checksum", cfg.Checksum, "event checksum: none, crc32c or sha256")
+		fs.StringVar(&cfg.PayloadSizeDistribution, "sizes", cfg.PayloadSizeDistribution, "payload size distribution: none, fixed, uniform, normal or weighted")
+		fs.Var((*list)(&cfg.Headers), "headers", "comma separated header modes: static, random, unique, large or many")
+	}
+
+	if cmd == cmdVerify {
+		fs.Var((*list)(&cfg.CompressionMatrix), "compression-matrix", "comma separated codec[:level] the verification is repeated with, e.g. none,gzip:9,lz4,zstd:3")
+		fs.StringVar(&cfg.Matrix, "matrix", cfg.Matrix, "settings the verification is repeated with, e.g. linger=0ms,5ms;acks=all,leader;members=1,3")
+		fs.StringVar(&cfg.MatrixCSV, "matrix-csv", cfg.MatrixCSV, "file the CSV comparison of the matrix runs is written to")
+		fs.StringVar(&cfg.MatrixMarkdown, "matrix-markdown", cfg.MatrixMarkdown, "file the Markdown comparison of the matrix runs is written to")
+		fs.BoolVar(&cfg.Offline, "offline", cfg.Offline, "reconcile the -manifest and -seen files of earlier runs without connecting to Kafka")
+	}
+
+	if cmd == cmdConsume || cmd == cmdVerify || cmd == cmdCanary || cmd == cmdCapacity {
+		fs.StringVar(&cfg.Group, "group", cfg.Group, "consumer group")
+	}
+
+	if cmd == cmdConsume || cmd == cmdVerify || cmd == cmdCanary || cmd == cmdCapacity {
+		fs.StringVar(&cfg.TargetSeeds, "target-seeds", cfg.TargetSeeds, "seed broker address of the cluster consumed from, when verifying replication")
+		fs.StringVar(&cfg.TargetTopic, "target-topic", cfg.TargetTopic, "topic consumed from, when verifying replication")
+	}
+
+	if cmd == cmdConsume || cmd == cmdVerify || cmd == cmdCapacity {
+		fs.StringVar(&cfg.SeenPath, "seen", cfg.SeenPath, "file the events consumed are listed in")
+		fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", cfg.IdleTimeout, "stop when no record is processed for this long")
+		fs.IntVar(&cfg.ConsumerFetchMaxBytes, "fetch-max-bytes", cfg.ConsumerFetchMaxBytes, "largest fetch response")
+		fs.IntVar(&cfg.ConsumerFetchMaxPartitionBytes, "fetch-max-partition-bytes", cfg.ConsumerFetchMaxPartitionBytes, "largest fetch response per partition")
+		fs.IntVar(&cfg.ConsumerFetchMinBytes, "fetch-min-bytes", cfg.ConsumerFetchMinBytes, "fetch response size waited for")
+		fs.DurationVar(&cfg.ConsumerFetchMaxWait, "fetch-max-wait", cfg.ConsumerFetchMaxWait, "longest wait for -fetch-min-bytes")
+		fs.IntVar(&cfg.ConsumerMembers, "members", cfg.ConsumerMembers, "group members started, each with its own client")
+	}
+
+	if cmd == cmdCapacity {
+		fs.Float64Var(&cfg.CapacityStart, "start-rate", cfg.CapacityStart, "first rate probed, in records per second")
+		fs.Float64Var(&cfg.CapacityPrecision, "precision", cfg.CapacityPrecision, "stop once the passing and failing rates are this close, relative to the failing one")
+		fs.IntVar(&cfg.CapacityProbes, "probes", cfg.CapacityProbes, "stop after this many probes")
+		fs.DurationVar(&cfg.CapacityWindow, "window", cfg.CapacityWindow, "how long every rate is sustained")
+		fs.DurationVar(&cfg.CapacityMaxP99, "max-p99", cfg.CapacityMaxP99, "highest p99 end-to-end latency of a passing probe")
+		fs.DurationVar(&cfg.CapacityMaxLag, "max-lag", cfg.CapacityMaxLag, "highest consumer group lag of a passing probe, in time at the probed rate")
+	}
+
+	if cmd == cmdCanary {
+		fs.DurationVar(&cfg.CanaryInterval, "interval", cfg.CanaryInterval, "how often a probe is produced to every partition")
+		fs.DurationVar(&cfg.CanaryTimeout, "timeout", cfg.CanaryTimeout, "a probe not consumed within this time is lost")
+	}
+
+	if cmd == cmdReplay {
+		fs.StringVar(&cfg.ManifestPath, "manifest", cfg.ManifestPath, "manifest of the events expected in the topic")
+		fs.StringVar(&cfg.ReplayFrom, "from", cfg.ReplayFrom, "start or an RFC3339 timestamp")
+	}
+
+	if cmd == cmdInspect {
+		fs.Var((*partitions)(&cfg.InspectPartitions), "partitions", "comma separated partitions tailed, all when empty")
+		fs.StringVar(&cfg.InspectFrom, "from", cfg.InspectFrom, "start, end, an offset or an RFC3339 timestamp")
+		fs.BoolVar(&cfg.InspectFollow, "follow", cfg.InspectFollow, "keep tailing instead of stopping at the end")
+		fs.StringVar(&cfg.InspectState, "state", cfg.InspectState, "only events in this state")
+		fs.StringVar(&cfg.InspectID, "id", cfg.InspectID, "only events whose ID contains this")
+		fs.IntVar(&cfg.InspectLimit, "limit", cfg.InspectLimit, "stop after this many matching records")
+	}
+
+	return fs
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+
+	fmt.Fprintf(out, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", c.name, c.help)
+	}
+
+	fmt.Fprintf(out, "\nFlags of %s:\n", fs.Name())
+	fs.PrintDefaults()
+}
+
+// list is a comma separated list flag.
+type list []string
+
+func (l *list) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *list) Set(s string) error {
+	*l = nil
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+
+	return nil
+}
+
+// partitions is a comma separated list of partitions flag.
+type partitions []int32
+
+func (p *partitions) String() string {
+	if p == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, len(*p))
+	for _, partition := range *p {
+		parts = append(parts, strconv.Itoa(int(partition)))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (p *partitions) Set(s string) error {
+	*p = nil
+
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+
+		partition, err := strconv.ParseInt(part, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid partition %q", part)
+		}
+		*p = append(*p, int32(partition))
+	}
+
+	return nil
+}
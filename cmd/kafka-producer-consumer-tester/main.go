@@ -1,28 +1,74 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"kafka-producer-consumer-tester/config"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"kafka-producer-consumer-tester/internal/app/inspector"
 	"kafka-producer-consumer-tester/internal/app/verifier"
+	"kafka-producer-consumer-tester/internal/pkg/admin"
+	"kafka-producer-consumer-tester/internal/pkg/auth"
+	"kafka-producer-consumer-tester/internal/pkg/codec"
 	"kafka-producer-consumer-tester/internal/pkg/consumer"
+	"kafka-producer-consumer-tester/internal/pkg/load"
 	"kafka-producer-consumer-tester/internal/pkg/logger"
+	"kafka-producer-consumer-tester/internal/pkg/metrics"
 	"kafka-producer-consumer-tester/internal/pkg/producer"
+	"kafka-producer-consumer-tester/internal/pkg/registry"
+	"kafka-producer-consumer-tester/internal/pkg/tracing"
+
+	"github.com/twmb/franz-go/plugin/kotel"
 )
 
+// Logger is implemented by both the terminal UI and the console loggers.
+type Logger interface {
+	metrics.Logger
+	Done() <-chan struct{}
+	Shutdown()
+}
+
 func main() {
-	err := run()
+	err := run(os.Args[1:])
 	if err != nil {
 		fmt.Printf("ERROR: Application has failed to tart %v\n", err)
 		panic(err)
 	}
 }
-func run() error {
+func run(args []string) error {
 	log.Println("INFO: Starting application")
 	defer log.Println("INFO: Application gracefully stopped")
 
-	logger, err := logger.New()
+	cfg, err := config.Get()
+	if err != nil {
+		log.Printf("ERROR: loading configuration: %v\n", err)
+		return err
+	}
+
+	cmd, args, err := command(args, cfg.Mode)
+	if err != nil {
+		usage(flagSet(cmdVerify, cfg))
+		return err
+	}
+
+	if err := flagSet(cmd, cfg).Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Printf("ERROR: invalid configuration: %v\n", err)
+		return err
+	}
+
+	logger, err := newLogger(cmd, cfg)
 	if err != nil {
 		log.Printf("ERROR: Failed to initialize logger: %v\n", err)
 		return err
@@ -32,43 +78,440 @@ func run() error {
 		logger.Shutdown()
 	}()
 
-	cfg, err := config.Get()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		select {
+		case <-logger.Done():
+			stop()
+		case <-ctx.Done():
+		}
+	}()
+
+	cdc, err := newCodec(cfg)
 	if err != nil {
-		logger.Errorf("loading configuration: %v", err)
+		logger.Errorf("initializing codec: %v", err)
 		return err
 	}
 
-	p, err := producer.New(producer.ProducerConfig{
-		Seeds: []string{cfg.Seeds},
-		Topic: cfg.Topic,
-		Group: cfg.Group,
-	}, logger)
+	if cfg.RegistryURL == "fake" {
+		f := registry.NewFake()
+		if cfg.RegistryURL, err = f.Start(); err != nil {
+			logger.Errorf("starting fake schema registry: %v", err)
+			return err
+		}
+		defer f.Shutdown()
+
+		logger.Infof("fake schema registry listening on %s", cfg.RegistryURL)
+	}
+
+	if cfg.RegistryURL != "" && !cfg.Offline {
+		subject := cfg.RegistrySubject
+		if subject == "" {
+			subject = cfg.Topic + "-value"
+		}
+
+		rc, err := registry.NewCodec(ctx, cdc, registry.New(registry.RegistryConfig{
+			URL:      cfg.RegistryURL,
+			User:     cfg.RegistryUser,
+			Password: cfg.RegistryPassword,
+		}), subject)
+		if err != nil {
+			logger.Errorf("initializing schema registry: %v", err)
+			return err
+		}
+
+		logger.Infof("%s schema registered under %s with ID %d", cfg.Codec, subject, rc.ID())
+		cdc = rc
+	}
+
+	if cmd == cmdInspect {
+		return inspect(ctx, cfg, cdc, logger)
+	}
+	if cmd == cmdVerify && cfg.Offline {
+		return reconcile(cfg, logger)
+	}
+	if cmd == cmdReplay {
+		return replay(ctx, cfg, cdc, logger)
+	}
+
+	m := metrics.New(metrics.MetricsConfig{Addr: cfg.MetricsAddr}, logger)
+	defer m.Shutdown()
+
+	t, err := tracing.New(tracing.TracingConfig{
+		Exporter:    cfg.TracingExporter,
+		File:        cfg.TracingFile,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	}, m)
 	if err != nil {
-		logger.Errorf("initializing producer: %v", err)
+		logger.Errorf("initializing tracing: %v", err)
 		return err
 	}
+	defer t.Shutdown()
+
+	if cmd == cmdCapacity {
+		return searchCapacity(ctx, cfg, cdc, m, t, logger)
+	}
+	if cmd == cmdVerify && (cfg.Matrix != "" || len(cfg.CompressionMatrix) > 0) {
+		return sweep(ctx, cfg, cdc, m, t, logger)
+	}
+
+	_, err = execute(ctx, cmd, cfg, cdc, m, t, logger)
+	return err
+}
+
+// execute creates the clients the command needs and runs it, returning the
+// report of the run.
+func execute(ctx context.Context, cmd string, cfg *config.Config, cdc codec.Codec, m *metrics.Metrics, t *tracing.Tracing, logger Logger) (*verifier.Report, error) {
+	// matrix runs and capacity probes share the metrics
+	m.Reset()
+
+	src, dst := source(cfg), target(cfg)
+	if cmd == cmdProduce {
+		dst = src // nothing is consumed
+	}
+
+	// only the clients the command needs are created, the verifier is given nil
+	// for the others
+	var p verifier.Producer
+	if cmd != cmdConsume {
+		pr, err := producer.New(producer.ProducerConfig{
+			Seeds: []string{src.seeds},
+			Topic: src.topic,
+			Group: cfg.Group,
+			Auth:  src.auth,
+
+			ManualPartitioning: cmd == cmdCanary,
+
+			Partitioner: cfg.Partitioner,
+			Partition:   cfg.PartitionerPartition,
+
+			BatchMaxBytes: int32(cfg.ProducerBatchMaxBytes),
+			Linger:        cfg.ProducerLinger,
+			Acks:          cfg.ProducerAcks,
+
+			Compression:      cfg.Compression,
+			CompressionLevel: cfg.CompressionLevel,
+
+			Tracer: t.Tracer(),
+		}, m)
+		if err != nil {
+			logger.Errorf("initializing producer: %v", err)
+			return nil, err
+		}
+		defer func() {
+			pr.Shutdown()
+			logger.Infof("producer shutted down")
+		}()
+
+		p = pr
+	}
+
+	var c verifier.Consumer
+	if cmd != cmdProduce {
+		cr := consumer.New(consumer.ConsumerConfig{
+			Seeds: []string{dst.seeds},
+			Topic: dst.topic,
+			Group: cfg.Group,
+			Auth:  dst.auth,
+
+			CommitAuditPath: cfg.CommitAuditPath,
+
+			FetchMinBytes:          int32(cfg.ConsumerFetchMinBytes),
+			FetchMaxBytes:          int32(cfg.ConsumerFetchMaxBytes),
+			FetchMaxPartitionBytes: int32(cfg.ConsumerFetchMaxPartitionBytes),
+			FetchMaxWait:           cfg.ConsumerFetchMaxWait,
+
+			Members: cfg.ConsumerMembers,
+
+			Tracer: t.Tracer(kotel.ConsumerGroup(cfg.Group)),
+		}, m)
+		defer func() {
+			cr.Shutdown()
+			logger.Infof("consumer shutted down")
+		}()
+
+		c = cr
+	}
+
+	// the source cluster is administered separately only when it is not the
+	// one consumed from
+	replicating := src != dst
+
+	a, err := newAdmin(dst, cfg.Group, m)
+	if err != nil {
+		return nil, err
+	}
 	defer func() {
-		p.Shutdown()
-		logger.Infof("producer shutted down")
+		a.Shutdown()
+		logger.Infof("admin shutted down")
 	}()
 
-	c := consumer.New(consumer.ConsumerConfig{
+	sa := a
+	if replicating {
+		if sa, err = newAdmin(src, cfg.Group, m); err != nil {
+			return nil, err
+		}
+		defer func() {
+			sa.Shutdown()
+			logger.Infof("source admin shutted down")
+		}()
+	}
+
+	if cfg.TopicPartitions > 0 && cmd != cmdConsume {
+		if err := sa.CreateTopic(ctx, cfg.TopicPartitions); err != nil {
+			logger.Errorf("creating topic: %v", err)
+			return nil, err
+		}
+	}
+
+	// replication is only verified when the events consumed were produced by
+	// this run
+	var from, to string
+	if replicating && cmd != cmdConsume {
+		from, to = src.String(), dst.String()
+	}
+
+	v := verifier.New(verifier.VerifierConfig{
+		Batches:   cfg.Batches,
+		BatchSize: cfg.BatchSize,
+
+		IdleTimeout:       cfg.IdleTimeout,
+		DeadlineBase:      cfg.DeadlineBase,
+		DeadlinePerRecord: cfg.DeadlinePerRecord,
+
+		LagInterval: cfg.LagInterval,
+
+		Shape: load.ShapeConfig{
+			Name:          cfg.LoadShape,
+			Base:          cfg.LoadBase,
+			Period:        cfg.LoadPeriod,
+			Steps:         cfg.LoadSteps,
+			SpikeDuration: cfg.LoadSpikeDuration,
+		},
+		Rate:     cfg.LoadRate,
+		ByteRate: cfg.LoadByteRate,
+		Warmup:   cfg.LoadWarmup,
+
+		SoakDuration:       cfg.SoakDuration,
+		SoakWindow:         cfg.SoakWindow,
+		SoakReportInterval: cfg.SoakReportInterval,
+
+		CanaryInterval:       cfg.CanaryInterval,
+		CanaryTimeout:        cfg.CanaryTimeout,
+		CanaryReportInterval: cfg.CanaryReportInterval,
+
+		ManifestPath:   cfg.ManifestPath,
+		SeenPath:       cfg.SeenPath,
+		ManifestFormat: cfg.ManifestFormat,
+
+		Codec: cdc,
+		Size: load.SizeConfig{
+			Distribution: cfg.PayloadSizeDistribution,
+			Size:         cfg.PayloadSize,
+			Min:          cfg.PayloadSizeMin,
+			Max:          cfg.PayloadSizeMax,
+			StdDev:       cfg.PayloadSizeStdDev,
+			Weights:      cfg.PayloadSizeWeights,
+		},
+
+		Checksum: cfg.Checksum,
+
+		KeyStrategy: cfg.KeyStrategy,
+		HotKeys:     cfg.HotKeys,
+		Partitioner: cfg.Partitioner,
+
+		Headers:     cfg.Headers,
+		HeaderSize:  cfg.HeaderSize,
+		HeaderCount: cfg.HeaderCount,
+
+		Source: from,
+		Target: to,
+
+		ReportPath: cfg.ReportPath,
+	}, p, c, sa, a, m)
+
+	switch cmd {
+	case cmdProduce:
+		err = v.Produce()
+	case cmdConsume:
+		err = v.Consume(ctx)
+	case cmdCanary:
+		err = v.Canary(ctx)
+	default:
+		err = v.Verify()
+	}
+	if err != nil {
+		logger.Errorf("%s: %v", cmd, err)
+		return nil, err
+	}
+
+	return v.Result(), nil
+}
+
+// newLogger returns the terminal UI logger, unless plain logs are requested,
+// stdout carries the inspected records or the manifests are reconciled offline.
+func newLogger(cmd string, cfg *config.Config) (Logger, error) {
+	if cfg.PlainLogs || cmd == cmdInspect || cfg.Offline {
+		return logger.NewConsole(), nil
+	}
+
+	return logger.New()
+}
+
+func inspect(ctx context.Context, cfg *config.Config, cdc codec.Codec, l Logger) error {
+	t := consumer.NewTailer(consumer.TailConfig{
+		Seeds:      []string{cfg.Seeds},
+		Topic:      cfg.Topic,
+		Auth:       source(cfg).auth,
+		Partitions: cfg.InspectPartitions,
+		From:       cfg.InspectFrom,
+		Follow:     cfg.InspectFollow,
+	}, l)
+
+	i := inspector.New(inspector.InspectorConfig{
+		State: cfg.InspectState,
+		ID:    cfg.InspectID,
+		Limit: cfg.InspectLimit,
+
+		Codec: cdc,
+	}, t, os.Stdout, l)
+
+	if err := i.Inspect(ctx); err != nil {
+		l.Errorf("inspect: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// reconcile compares the manifests of earlier produce and consume runs, no
+// client is created.
+func reconcile(cfg *config.Config, l Logger) error {
+	v := verifier.New(verifier.VerifierConfig{
+		ManifestPath: cfg.ManifestPath,
+		SeenPath:     cfg.SeenPath,
+
+		ReportPath: cfg.ReportPath,
+	}, nil, nil, nil, nil, l)
+
+	if err := v.Reconcile(); err != nil {
+		l.Errorf("reconcile: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// replay checks the events listed in the manifest are still in the topic. It
+// reads the topic directly, leaving no consumer group behind.
+func replay(ctx context.Context, cfg *config.Config, cdc codec.Codec, l Logger) error {
+	t := consumer.NewTailer(consumer.TailConfig{
 		Seeds: []string{cfg.Seeds},
 		Topic: cfg.Topic,
-		Group: cfg.Group,
-	}, logger)
-	defer func() {
-		c.Shutdown()
-		logger.Infof("consumer shutted down")
-	}()
+		Auth:  source(cfg).auth,
+		From:  cfg.ReplayFrom,
+	}, l)
 
-	v := verifier.New(p, c, logger)
+	v := verifier.New(verifier.VerifierConfig{
+		ManifestPath: cfg.ManifestPath,
 
-	err = v.Verify()
-	if err != nil {
-		logger.Errorf("verification: %v", err)
+		Codec: cdc,
+
+		ReportPath: cfg.ReportPath,
+	}, nil, nil, nil, nil, l)
+
+	if err := v.Replay(ctx, t); err != nil {
+		l.Errorf("replay: %v", err)
 		return err
 	}
 
 	return nil
 }
+
+// cluster is where events are produced to or consumed from.
+type cluster struct {
+	seeds string
+	topic string
+	auth  auth.AuthConfig
+}
+
+func (c cluster) String() string {
+	return c.seeds + "/" + c.topic
+}
+
+// source returns the cluster events are produced to.
+func source(cfg *config.Config) cluster {
+	return cluster{
+		seeds: cfg.Seeds,
+		topic: cfg.Topic,
+		auth: auth.AuthConfig{
+			Mechanism: cfg.SASLMechanism,
+			User:      cfg.SASLUser,
+			Password:  cfg.SASLPassword,
+			TLS:       cfg.TLS,
+			CAFile:    cfg.TLSCAFile,
+		},
+	}
+}
+
+// target returns the cluster events are consumed from, the source one unless
+// another cluster or topic is configured.
+func target(cfg *config.Config) cluster {
+	c := source(cfg)
+
+	if cfg.TargetSeeds != "" {
+		c.seeds = cfg.TargetSeeds
+		c.auth = auth.AuthConfig{
+			Mechanism: cfg.TargetSASLMechanism,
+			User:      cfg.TargetSASLUser,
+			Password:  cfg.TargetSASLPassword,
+			TLS:       cfg.TargetTLS,
+			CAFile:    cfg.TargetTLSCAFile,
+		}
+	}
+	if cfg.TargetTopic != "" {
+		c.topic = cfg.TargetTopic
+	}
+
+	return c
+}
+
+func newAdmin(c cluster, group string, l admin.Logger) (*admin.Admin, error) {
+	a, err := admin.New(admin.AdminConfig{
+		Seeds: []string{c.seeds},
+		Topic: c.topic,
+		Group: group,
+		Auth:  c.auth,
+	}, l)
+	if err != nil {
+		l.Errorf("initializing admin: %v", err)
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// newCodec returns the configured codec, writing several schema versions when
+// testing schema evolution.
+func newCodec(cfg *config.Config) (codec.Codec, error) {
+	if len(cfg.EvolutionWriters) == 0 && len(cfg.EvolutionReaders) == 0 {
+		return codec.New(cfg.Codec)
+	}
+
+	if cfg.RegistryURL != "" {
+		return nil, errors.New("schema evolution can't be tested through a schema registry")
+	}
+	// the versioned schemas have no padding field, so values would never grow
+	if cfg.PayloadSizeDistribution != load.NoPadding && cfg.PayloadSizeDistribution != "" {
+		return nil, errors.New("payload size distributions can't be combined with schema evolution")
+	}
+	// nor a checksum field, so every event would be accepted unchecked
+	if cfg.Checksum != codec.NoChecksum && cfg.Checksum != "" {
+		return nil, errors.New("checksums can't be combined with schema evolution")
+	}
+
+	return codec.NewEvolution(cfg.Codec, cfg.EvolutionWriters, cfg.EvolutionReaders)
+}
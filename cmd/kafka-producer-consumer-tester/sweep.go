@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"kafka-producer-consumer-tester/config"
+	"kafka-producer-consumer-tester/internal/app/matrix"
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+	"kafka-producer-consumer-tester/internal/pkg/metrics"
+	"kafka-producer-consumer-tester/internal/pkg/producer"
+	"kafka-producer-consumer-tester/internal/pkg/tracing"
+)
+
+// sweep repeats the verification with every combination of the settings of
+// the matrix, one run after the other, logging their results side by side and
+// writing them to the report and the comparison tables.
+func sweep(ctx context.Context, cfg *config.Config, cdc codec.Codec, m *metrics.Metrics, t *tracing.Tracing, logger Logger) error {
+	dims, err := matrix.Parse(cfg.Matrix)
+	if err != nil {
+		return err
+	}
+	if len(cfg.CompressionMatrix) > 0 {
+		dims = append(dims, matrix.Dimension{Name: "compression", Values: cfg.CompressionMatrix})
+	}
+
+	// every setting is checked before the first run rather than failing halfway
+	combinations := matrix.Combinations(dims)
+	for _, settings := range combinations {
+		run := *cfg
+		for _, s := range settings {
+			if err := apply(&run, s); err != nil {
+				return err
+			}
+		}
+		if err := check(&run); err != nil {
+			return fmt.Errorf("invalid matrix run %s: %v", matrix.Name(settings), err)
+		}
+	}
+
+	var results []matrix.Result
+
+	for i, settings := range combinations {
+		run := *cfg
+		run.ReportPath = ""
+		for _, s := range settings {
+			apply(&run, s)
+		}
+
+		logger.Infof("matrix run %d/%d: %s", i+1, len(combinations), matrix.Name(settings))
+
+		cpu := matrix.CPUTime()
+		r, err := execute(ctx, cmdVerify, &run, cdc, m, t, logger)
+		results = append(results, matrix.Summarize(settings, r, matrix.CPUTime()-cpu, err))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	for _, line := range matrix.Lines(results) {
+		logger.Info(line)
+	}
+
+	if err := matrix.WriteCSV(cfg.MatrixCSV, results); err != nil {
+		return err
+	}
+	if err := matrix.WriteMarkdown(cfg.MatrixMarkdown, results); err != nil {
+		return err
+	}
+
+	return matrix.WriteJSON(cfg.ReportPath, results)
+}
+
+// check returns an error if a matrix run would fail to start with the
+// configuration.
+func check(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if err := producer.ValidCompression(cfg.Compression, cfg.CompressionLevel); err != nil {
+		return err
+	}
+	if cfg.ConsumerMembers < 1 {
+		return fmt.Errorf("%d consumer group members", cfg.ConsumerMembers)
+	}
+
+	return producer.ValidAcks(cfg.ProducerAcks)
+}
+
+// apply sets the setting of a matrix run on its configuration.
+func apply(cfg *config.Config, s matrix.Setting) error {
+	var err error
+
+	switch s.Name {
+	case "compression":
+		name, level, leveled := strings.Cut(s.Value, ":")
+		cfg.Compression, cfg.CompressionLevel = name, 0
+		if leveled {
+			cfg.CompressionLevel, err = strconv.Atoi(level)
+		}
+	case "linger":
+		cfg.ProducerLinger, err = time.ParseDuration(s.Value)
+	case "batch-bytes":
+		cfg.ProducerBatchMaxBytes, err = strconv.Atoi(s.Value)
+	case "acks":
+		cfg.ProducerAcks = s.Value
+	case "fetch-min-bytes":
+		cfg.ConsumerFetchMinBytes, err = strconv.Atoi(s.Value)
+	case "fetch-max-bytes":
+		cfg.ConsumerFetchMaxBytes, err = strconv.Atoi(s.Value)
+	case "fetch-max-partition-bytes":
+		cfg.ConsumerFetchMaxPartitionBytes, err = strconv.Atoi(s.Value)
+	case "fetch-max-wait":
+		cfg.ConsumerFetchMaxWait, err = time.ParseDuration(s.Value)
+	case "members":
+		cfg.ConsumerMembers, err = strconv.Atoi(s.Value)
+	case "partitions":
+		// a topic per partition count, as partitions can't be removed
+		var p int64
+		p, err = strconv.ParseInt(s.Value, 10, 32)
+		if err == nil && p < 1 {
+			err = fmt.Errorf("a topic needs a partition at least")
+		}
+		cfg.Topic, cfg.TopicPartitions = fmt.Sprintf("%s-p%d", cfg.Topic, p), int32(p)
+	default:
+		return fmt.Errorf("unknown matrix setting %q", s.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid matrix setting %s=%s: %v", s.Name, s.Value, err)
+	}
+
+	return nil
+}
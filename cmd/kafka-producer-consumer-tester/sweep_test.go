@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"kafka-producer-consumer-tester/config"
+	"kafka-producer-consumer-tester/internal/app/matrix"
+)
+
+func TestApplyCompression(t *testing.T) {
+	tests := []struct {
+		value       string
+		compression string
+		level       int
+		ok          bool // applied and checked
+	}{
+		{"none", "none", 0, true},
+		{"snappy", "snappy", 0, true},
+		{"lz4", "lz4", 0, true},
+		{"gzip:1", "gzip", 1, true},
+		{"gzip:9", "gzip", 9, true},
+		{"gzip:-2", "gzip", -2, true},
+		{"zstd", "zstd", 0, true},
+		{"zstd:3", "zstd", 3, true},
+
+		{"gzip:10", "gzip", 10, false},
+		{"zstd:22", "zstd", 22, false},
+		{"lz4:9", "lz4", 9, false},
+		{"snappy:1", "snappy", 1, false},
+		{"brotli", "brotli", 0, false},
+	}
+	for _, tt := range tests {
+		cfg, err := config.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		run := *cfg
+		run.CompressionLevel = 5 // from the environment, not kept by codecs without level
+
+		if err := apply(&run, matrix.Setting{Name: "compression", Value: tt.value}); err != nil {
+			t.Errorf("applying %q: %v", tt.value, err)
+			continue
+		}
+		if run.Compression != tt.compression || run.CompressionLevel != tt.level {
+			t.Errorf("applying %q: got %q at level %d, want %q at %d", tt.value, run.Compression, run.CompressionLevel, tt.compression, tt.level)
+		}
+		if err := check(&run); (err == nil) != tt.ok {
+			t.Errorf("checking %q: got error %v", tt.value, err)
+		}
+	}
+}
+
+func TestApplyInvalidCompressionLevel(t *testing.T) {
+	for _, value := range []string{"gzip:", "gzip:best", "zstd:3.5", "zstd:3:1"} {
+		var cfg config.Config
+		if err := apply(&cfg, matrix.Setting{Name: "compression", Value: value}); err == nil {
+			t.Errorf("applying %q: got no error", value)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -13,6 +14,130 @@ type Config struct {
 	Seeds string `envconfig:"KAFKA_SEEDS"`
 	Topic string `envconfig:"KAFKA_TOPIC"`
 	Group string `envconfig:"KAFKA_Group"`
+
+	TopicPartitions int32 `envconfig:"KAFKA_TOPIC_PARTITIONS"` // the topic is created with this many partitions unless it exists, left as is when 0
+
+	SASLMechanism string `envconfig:"KAFKA_SASL_MECHANISM"` // plain, scram-sha-256 or scram-sha-512, no authentication when empty
+	SASLUser      string `envconfig:"KAFKA_SASL_USER"`
+	SASLPassword  string `envconfig:"KAFKA_SASL_PASSWORD"`
+	TLS           bool   `envconfig:"KAFKA_TLS"`
+	TLSCAFile     string `envconfig:"KAFKA_TLS_CA_FILE"` // PEM bundle trusted instead of the system roots
+
+	// the cluster and topic consumed from, when verifying replication; the
+	// target authentication settings only apply when TARGET_KAFKA_SEEDS is set
+	TargetSeeds         string `envconfig:"TARGET_KAFKA_SEEDS"`
+	TargetTopic         string `envconfig:"TARGET_KAFKA_TOPIC"`
+	TargetSASLMechanism string `envconfig:"TARGET_KAFKA_SASL_MECHANISM"`
+	TargetSASLUser      string `envconfig:"TARGET_KAFKA_SASL_USER"`
+	TargetSASLPassword  string `envconfig:"TARGET_KAFKA_SASL_PASSWORD"`
+	TargetTLS           bool   `envconfig:"TARGET_KAFKA_TLS"`
+	TargetTLSCAFile     string `envconfig:"TARGET_KAFKA_TLS_CA_FILE"`
+
+	Mode      string `envconfig:"MODE" default:"verify"` // command run when none is given: produce, consume, verify, inspect, canary, replay or capacity
+	PlainLogs bool   `envconfig:"PLAIN_LOGS"`            // log to stderr instead of the interactive terminal UI
+
+	Batches   int `envconfig:"MESSAGE_BATCHES" default:"1000"`
+	BatchSize int `envconfig:"MESSAGE_BATCH_SIZE" default:"1000"`
+
+	IdleTimeout       time.Duration `envconfig:"COMPLETION_IDLE_TIMEOUT" default:"30s"`          // give up when no record is processed for this long
+	DeadlineBase      time.Duration `envconfig:"COMPLETION_DEADLINE_BASE" default:"60s"`         // fixed part of the overall completion deadline
+	DeadlinePerRecord time.Duration `envconfig:"COMPLETION_DEADLINE_PER_RECORD" default:"100us"` // added to the deadline for every produced record
+
+	Codec string `envconfig:"CODEC" default:"json"` // json, avro, protobuf or msgpack
+
+	EvolutionWriters []string `envconfig:"EVOLUTION_WRITERS"` // schema versions written in turn, v1, v2 or v3; all when only readers are set
+	EvolutionReaders []string `envconfig:"EVOLUTION_READERS"` // schema versions every value is read with; all when only writers are set, no evolution testing when both are empty
+
+	RegistryURL      string `envconfig:"SCHEMA_REGISTRY_URL"`  // values are framed in the Confluent wire format when set; fake runs an in-process registry
+	RegistryUser     string `envconfig:"SCHEMA_REGISTRY_USER"` // basic auth credentials
+	RegistryPassword string `envconfig:"SCHEMA_REGISTRY_PASSWORD"`
+	RegistrySubject  string `envconfig:"SCHEMA_REGISTRY_SUBJECT"` // subject the schema is registered under, <topic>-value when empty
+
+	KeyStrategy string `envconfig:"KEY_STRATEGY" default:"none"` // none, id, hot or random
+	HotKeys     int    `envconfig:"KEY_HOT_KEYS" default:"10"`   // number of keys of the hot strategy
+
+	Partitioner          string `envconfig:"PARTITIONER" default:"default"` // default, sticky, round-robin, murmur2, least-backup or manual
+	PartitionerPartition int32  `envconfig:"PARTITIONER_PARTITION"`         // partition every record is sent to with the manual partitioner
+
+	Headers     []string `envconfig:"HEADERS"`                            // static, random, unique, large or many; no headers when empty
+	HeaderSize  int      `envconfig:"HEADERS_LARGE_SIZE" default:"16384"` // value size of the large header
+	HeaderCount int      `envconfig:"HEADERS_MANY" default:"100"`         // number of headers of the many mode
+
+	PayloadSizeDistribution string `envconfig:"PAYLOAD_SIZE_DISTRIBUTION" default:"none"` // none, fixed, uniform, normal or weighted
+	PayloadSize             int    `envconfig:"PAYLOAD_SIZE"`                             // fixed size, or mean of the normal distribution, in bytes
+	PayloadSizeMin          int    `envconfig:"PAYLOAD_SIZE_MIN"`                         // smallest size of the uniform and normal distributions
+	PayloadSizeMax          int    `envconfig:"PAYLOAD_SIZE_MAX"`                         // largest size of the uniform and normal distributions
+	PayloadSizeStdDev       int    `envconfig:"PAYLOAD_SIZE_STDDEV"`                      // standard deviation of the normal distribution
+	PayloadSizeWeights      string `envconfig:"PAYLOAD_SIZE_WEIGHTS"`                     // size:weight pairs of the weighted distribution, e.g. 100:80,10000:15,2000000:5
+
+	Compression       string   `envconfig:"COMPRESSION" default:"snappy"` // none, gzip, snappy, lz4 or zstd
+	CompressionLevel  int      `envconfig:"COMPRESSION_LEVEL"`            // level of the gzip (-2 to 9) and zstd (1 to 4) codecs, their default when 0
+	CompressionMatrix []string `envconfig:"COMPRESSION_MATRIX"`           // codec[:level] verify is repeated with, comparing the runs
+
+	Matrix         string `envconfig:"MATRIX"`          // settings verify is repeated with, every combination once, e.g. linger=0ms,5ms;acks=all,leader
+	MatrixCSV      string `envconfig:"MATRIX_CSV"`      // CSV comparison of the matrix runs, not written when empty
+	MatrixMarkdown string `envconfig:"MATRIX_MARKDOWN"` // Markdown comparison of the matrix runs, not written when empty
+
+	ProducerBatchMaxBytes          int           `envconfig:"PRODUCER_BATCH_MAX_BYTES" default:"1000000"`           // largest batch, and so record, produced
+	ProducerLinger                 time.Duration `envconfig:"PRODUCER_LINGER" default:"5ms"`                        // how long a batch waits for more records before it is sent
+	ProducerAcks                   string        `envconfig:"PRODUCER_ACKS" default:"all"`                          // all, leader or none
+	ConsumerFetchMinBytes          int           `envconfig:"CONSUMER_FETCH_MIN_BYTES" default:"1000000"`           // fetch response size waited for
+	ConsumerFetchMaxBytes          int           `envconfig:"CONSUMER_FETCH_MAX_BYTES" default:"2000000"`           // largest fetch response
+	ConsumerFetchMaxPartitionBytes int           `envconfig:"CONSUMER_FETCH_MAX_PARTITION_BYTES" default:"1048576"` // largest fetch response per partition
+	ConsumerFetchMaxWait           time.Duration `envconfig:"CONSUMER_FETCH_MAX_WAIT" default:"5s"`                 // longest wait for CONSUMER_FETCH_MIN_BYTES
+	ConsumerMembers                int           `envconfig:"CONSUMER_MEMBERS" default:"1"`                         // group members started, each with its own client
+
+	Checksum string `envconfig:"CHECKSUM" default:"none"` // none, crc32c or sha256
+
+	LagInterval time.Duration `envconfig:"LAG_INTERVAL" default:"2s"` // how often the consumer group lag is sampled
+
+	LoadShape         string        `envconfig:"LOAD_SHAPE" default:"constant"`    // constant, ramp, step, spike or sine
+	LoadRate          float64       `envconfig:"LOAD_RATE"`                        // peak records per second, unlimited when 0
+	LoadByteRate      float64       `envconfig:"LOAD_BYTE_RATE"`                   // peak bytes per second, unlimited when 0
+	LoadBase          float64       `envconfig:"LOAD_BASE" default:"0.1"`          // fraction of the peak rate the shape starts from
+	LoadPeriod        time.Duration `envconfig:"LOAD_PERIOD" default:"60s"`        // ramp length, step length, spike interval or sine period
+	LoadSteps         int           `envconfig:"LOAD_STEPS" default:"4"`           // number of steps of the step shape
+	LoadSpikeDuration time.Duration `envconfig:"LOAD_SPIKE_DURATION" default:"5s"` // how long each spike lasts
+	LoadWarmup        time.Duration `envconfig:"LOAD_WARMUP"`                      // produced but excluded from throughput stats
+
+	SoakDuration       time.Duration `envconfig:"SOAK_DURATION"`                     // produce for this long instead of MESSAGE_BATCHES batches
+	SoakWindow         time.Duration `envconfig:"SOAK_WINDOW" default:"5m"`          // time a record has to be processed before it is verified and evicted
+	SoakReportInterval time.Duration `envconfig:"SOAK_REPORT_INTERVAL" default:"5m"` // how often interim reports are written
+
+	CanaryInterval       time.Duration `envconfig:"CANARY_INTERVAL" default:"1s"`        // how often a probe is produced to every partition
+	CanaryTimeout        time.Duration `envconfig:"CANARY_TIMEOUT" default:"30s"`        // a probe not consumed within this time is lost
+	CanaryReportInterval time.Duration `envconfig:"CANARY_REPORT_INTERVAL" default:"1m"` // how often the canary metrics are logged and written
+
+	CapacityStart     float64       `envconfig:"CAPACITY_START" default:"1000"`     // first rate probed, in records per second
+	CapacityPrecision float64       `envconfig:"CAPACITY_PRECISION" default:"0.05"` // the search stops once the passing and failing rates are this close, relative to the failing one
+	CapacityProbes    int           `envconfig:"CAPACITY_PROBES" default:"12"`      // the search stops after this many probes
+	CapacityWindow    time.Duration `envconfig:"CAPACITY_WINDOW" default:"1m"`      // how long every rate is sustained
+	CapacityMaxP99    time.Duration `envconfig:"CAPACITY_MAX_P99" default:"10s"`    // highest p99 end-to-end latency of a passing probe
+	CapacityMaxLag    time.Duration `envconfig:"CAPACITY_MAX_LAG" default:"10s"`    // highest consumer group lag of a passing probe, in time at the probed rate
+
+	TracingExporter    string  `envconfig:"TRACING_EXPORTER" default:"none"`                               // none, otlp or file
+	TracingFile        string  `envconfig:"TRACING_FILE" default:"traces.json"`                            // destination of the file exporter
+	TracingServiceName string  `envconfig:"TRACING_SERVICE_NAME" default:"kafka-producer-consumer-tester"` // service.name of the emitted spans
+	TracingSampleRatio float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`                              // share of traces sampled
+
+	ManifestPath   string `envconfig:"MANIFEST_PATH"`                    // events sent are listed here, not written when empty
+	SeenPath       string `envconfig:"SEEN_PATH"`                        // events consumed are listed here, not written when empty
+	ManifestFormat string `envconfig:"MANIFEST_FORMAT" default:"ndjson"` // ndjson or binary
+	Offline        bool   `envconfig:"OFFLINE"`                          // verify reconciles the manifest and seen files instead of running
+
+	ReplayFrom string `envconfig:"REPLAY_FROM" default:"start"` // start or an RFC3339 timestamp the topic is replayed from
+
+	InspectPartitions []int32 `envconfig:"INSPECT_PARTITIONS"`           // partitions tailed, all when empty
+	InspectFrom       string  `envconfig:"INSPECT_FROM" default:"start"` // start, end, an offset or an RFC3339 timestamp
+	InspectFollow     bool    `envconfig:"INSPECT_FOLLOW"`               // keep tailing instead of stopping at the end
+	InspectState      string  `envconfig:"INSPECT_STATE"`                // only events in this state
+	InspectID         string  `envconfig:"INSPECT_ID"`                   // only events whose ID contains this
+	InspectLimit      int     `envconfig:"INSPECT_LIMIT"`                // stop after this many matching records
+
+	MetricsAddr string `envconfig:"METRICS_ADDR"` // listen address of the Prometheus /metrics endpoint, e.g. :9090
+
+	ReportPath      string `envconfig:"REPORT_PATH"`       // JSON report destination, not written when empty
+	CommitAuditPath string `envconfig:"COMMIT_AUDIT_PATH"` // NDJSON trail of every offset commit, not written when empty
 }
 
 var (
@@ -34,8 +159,53 @@ func Get() (*Config, error) {
 		err := envconfig.Process("", &config)
 		if err != nil {
 			configError = fmt.Errorf("error processing config: %v", err)
+			return
 		}
+		configError = config.Validate()
 	})
 
 	return &config, configError
 }
+
+// Validate rejects the durations the run can't work with, as tickers panic on
+// non-positive intervals and a zero idle timeout gives up right away. It is
+// called again once flags override the environment.
+func (c *Config) Validate() error {
+	positive := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"COMPLETION_IDLE_TIMEOUT (-idle-timeout)", c.IdleTimeout},
+		{"LAG_INTERVAL", c.LagInterval},
+		{"SOAK_WINDOW", c.SoakWindow},
+		{"SOAK_REPORT_INTERVAL", c.SoakReportInterval},
+		{"CANARY_INTERVAL (-interval)", c.CanaryInterval},
+		{"CANARY_TIMEOUT (-timeout)", c.CanaryTimeout},
+		{"CANARY_REPORT_INTERVAL", c.CanaryReportInterval},
+		{"CAPACITY_WINDOW (-window)", c.CapacityWindow},
+	}
+	for _, p := range positive {
+		if p.d <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", p.name, p.d)
+		}
+	}
+
+	nonNegative := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"COMPLETION_DEADLINE_BASE", c.DeadlineBase},
+		{"COMPLETION_DEADLINE_PER_RECORD", c.DeadlinePerRecord},
+		{"LOAD_WARMUP", c.LoadWarmup},
+		{"SOAK_DURATION (-duration)", c.SoakDuration},
+		{"PRODUCER_LINGER (-linger)", c.ProducerLinger},
+		{"CONSUMER_FETCH_MAX_WAIT (-fetch-max-wait)", c.ConsumerFetchMaxWait},
+	}
+	for _, n := range nonNegative {
+		if n.d < 0 {
+			return fmt.Errorf("%s can't be negative, got %s", n.name, n.d)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package capacity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/app/matrix"
+	"kafka-producer-consumer-tester/internal/app/verifier"
+)
+
+type Logger interface {
+	Info(string)
+	Infof(string, ...any)
+}
+
+type CapacityConfig struct {
+	Start     float64       // first rate probed, in records per second
+	Precision float64       // the search stops once the passing and failing rates are this close, relative to the failing one
+	Probes    int           // the search stops after this many probes
+	Window    time.Duration // how long every rate is sustained
+
+	MaxP99 time.Duration // highest p99 end-to-end latency of a passing probe
+	MaxLag time.Duration // highest consumer group lag of a passing probe, in time at the probed rate
+}
+
+// Probe is the outcome of sustaining a rate for the window.
+type Probe struct {
+	Rate float64 `json:"rate"`
+
+	matrix.Result
+
+	MaxLag time.Duration `json:"max_lag"` // the largest lag sampled, divided by the rate
+	Passed bool          `json:"passed"`
+	Reason string        `json:"reason,omitempty"` // why the probe failed
+}
+
+// Result is the discovered capacity of the topic.
+type Result struct {
+	Capacity    float64       `json:"capacity"`      // highest passing rate, in records per second, 0 when none passed
+	BytesPerSec float64       `json:"bytes_per_sec"` // produce throughput of the highest passing probe
+	LatencyP99  time.Duration `json:"latency_p99"`   // p99 latency of the highest passing probe
+	Ceiling     float64       `json:"ceiling"`       // lowest failing rate, 0 when none failed and the capacity is only a lower bound
+	Partitions  int           `json:"partitions"`
+
+	Window time.Duration `json:"window"`
+	MaxP99 time.Duration `json:"max_p99"`
+	MaxLag time.Duration `json:"max_lag"`
+
+	Probes []Probe `json:"probes"`
+}
+
+// Run runs the verification at the given rate for the window, returning its
+// report.
+type Run func(ctx context.Context, rate float64) (*verifier.Report, error)
+
+// Search looks for the highest rate sustained within the latency and lag
+// bounds: it doubles the rate from the start one until a probe fails, then
+// bisects between the highest passing and the lowest failing rates.
+func Search(ctx context.Context, cfg CapacityConfig, run Run, l Logger) Result {
+	res := Result{Window: cfg.Window, MaxP99: cfg.MaxP99, MaxLag: cfg.MaxLag}
+
+	rate := math.Max(math.Round(cfg.Start), 1)
+	for i := 0; i < cfg.Probes && ctx.Err() == nil; i++ {
+		l.Infof("probe %d/%d: sustaining %.0f records/s for %s", i+1, cfg.Probes, rate, cfg.Window)
+
+		cpu := matrix.CPUTime()
+		r, err := run(ctx, rate)
+		p := evaluate(cfg, rate, r, matrix.CPUTime()-cpu, err)
+		res.Probes = append(res.Probes, p)
+
+		if p.Passed {
+			l.Infof("probe at %.0f records/s passed", rate)
+			res.Capacity, res.BytesPerSec, res.LatencyP99 = rate, p.BytesPerSec, p.LatencyP99
+		} else {
+			l.Infof("probe at %.0f records/s failed: %s", rate, p.Reason)
+			res.Ceiling = rate
+		}
+		if r != nil && r.Distribution != nil {
+			res.Partitions = len(r.Distribution.Partitions)
+		}
+
+		if res.Ceiling == 0 {
+			rate *= 2
+			continue
+		}
+		if (res.Ceiling-res.Capacity)/res.Ceiling <= cfg.Precision {
+			break
+		}
+
+		next := math.Round((res.Capacity + res.Ceiling) / 2)
+		if next <= res.Capacity || next >= res.Ceiling {
+			break // no whole rate is left in between
+		}
+		rate = next
+	}
+
+	return res
+}
+
+// evaluate tells whether the probe at the rate passed, and why not.
+func evaluate(cfg CapacityConfig, rate float64, r *verifier.Report, cpu time.Duration, err error) Probe {
+	p := Probe{
+		Rate:   rate,
+		Result: matrix.Summarize([]matrix.Setting{{Name: "rate", Value: fmt.Sprintf("%.0f", rate)}}, r, cpu, err),
+	}
+	if r != nil {
+		p.MaxLag = time.Duration(float64(r.MaxLag) / rate * float64(time.Second))
+	}
+
+	switch {
+	case p.Err != "":
+		p.Reason = p.Err
+	case !p.Correct:
+		p.Reason = fmt.Sprintf("%d of %d records processed, loss path %s, %d errors", p.Processed, p.Generated, p.LossPath, p.Errors)
+	case p.RecordsPerSec < rate*0.95:
+		p.Reason = fmt.Sprintf("produced %.0f records/s only", p.RecordsPerSec)
+	case p.LatencyP99 > cfg.MaxP99:
+		p.Reason = fmt.Sprintf("p99 latency %s above %s", p.LatencyP99, cfg.MaxP99)
+	case p.MaxLag > cfg.MaxLag:
+		p.Reason = fmt.Sprintf("lag of %s above %s", p.MaxLag.Round(time.Millisecond), cfg.MaxLag)
+	default:
+		p.Passed = true
+	}
+
+	return p
+}
+
+// Lines returns the probes as an aligned text table followed by the capacity
+// found.
+func Lines(res Result) []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "rate\trecords/s\tbytes/s\tp99\tmax lag\tgenerated\tprocessed\tverdict\t")
+	for _, p := range res.Probes {
+		verdict := "passed"
+		if !p.Passed {
+			verdict = "failed: " + p.Reason
+		}
+		fmt.Fprintf(w, "%.0f\t%.0f\t%.0f\t%s\t%s\t%d\t%d\t%s\t\n",
+			p.Rate, p.RecordsPerSec, p.BytesPerSec, p.LatencyP99, p.MaxLag.Round(time.Millisecond), p.Generated, p.Processed, verdict)
+	}
+	w.Flush()
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	switch {
+	case res.Capacity == 0:
+		lines = append(lines, fmt.Sprintf("no rate was sustained with a p99 under %s and a lag under %s", res.MaxP99, res.MaxLag))
+	case res.Ceiling == 0:
+		lines = append(lines, fmt.Sprintf("capacity of %d partitions: at least %.0f records/s (%.0f bytes/s), no probe failed", res.Partitions, res.Capacity, res.BytesPerSec))
+	default:
+		lines = append(lines, fmt.Sprintf("capacity of %d partitions: %.0f records/s (%.0f bytes/s), %.0f records/s failed", res.Partitions, res.Capacity, res.BytesPerSec, res.Ceiling))
+	}
+
+	return lines
+}
+
+// WriteJSON writes the result to path, unless it is empty.
+func WriteJSON(path string, res Result) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
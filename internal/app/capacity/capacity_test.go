@@ -0,0 +1,169 @@
+package capacity
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/app/verifier"
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+)
+
+type discard struct{}
+
+func (discard) Info(string)          {}
+func (discard) Infof(string, ...any) {}
+
+var cfg = CapacityConfig{Start: 100, Precision: 0.05, Probes: 20, Window: time.Minute, MaxP99: time.Second, MaxLag: time.Second}
+
+// report returns the report of a correct run at the rate, its p99 latency
+// climbing past the bound above the limit.
+func report(rate, limit float64) *verifier.Report {
+	r := &verifier.Report{
+		Generated:    1000,
+		Processed:    1000,
+		LossPath:     "none",
+		Throughput:   &verifier.Throughput{RecordsPerSec: rate, BytesPerSec: rate * 100},
+		Latency:      stats.Summary{P99: 10 * time.Millisecond},
+		Distribution: &verifier.Distribution{Partitions: map[int32]int64{0: 500, 1: 500}},
+	}
+	if rate > limit {
+		r.Latency.P99 = time.Minute
+	}
+	return r
+}
+
+func rates(res Result) []float64 {
+	var rates []float64
+	for _, p := range res.Probes {
+		rates = append(rates, p.Rate)
+	}
+	return rates
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      CapacityConfig
+		limit    float64
+		rates    []float64
+		capacity float64
+		ceiling  float64
+	}{
+		{
+			name:  "doubles then bisects down to the precision",
+			cfg:   cfg,
+			limit: 1000,
+			rates: []float64{100, 200, 400, 800, 1600, 1200, 1000, 1100, 1050},
+			// 1050 is within 5% of 1000
+			capacity: 1000, ceiling: 1050,
+		},
+		{
+			name:     "runs out of probes before failing",
+			cfg:      CapacityConfig{Start: 10, Precision: 0.05, Probes: 3, MaxP99: time.Second, MaxLag: time.Second},
+			limit:    1e9,
+			rates:    []float64{10, 20, 40},
+			capacity: 40,
+		},
+		{
+			name:     "no whole rate left in between",
+			cfg:      CapacityConfig{Start: 1, Probes: 20, MaxP99: time.Second, MaxLag: time.Second},
+			limit:    2,
+			rates:    []float64{1, 2, 4, 3},
+			capacity: 2, ceiling: 3,
+		},
+		{
+			name:    "nothing sustained",
+			cfg:     CapacityConfig{Start: 100, Precision: 0.05, Probes: 5, MaxP99: time.Second, MaxLag: time.Second},
+			limit:   0,
+			rates:   []float64{100, 50, 25, 13, 7},
+			ceiling: 7,
+		},
+		{
+			name:     "starts at a whole rate",
+			cfg:      CapacityConfig{Start: 0.2, Probes: 2, MaxP99: time.Second, MaxLag: time.Second},
+			limit:    1e9,
+			rates:    []float64{1, 2},
+			capacity: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func(_ context.Context, rate float64) (*verifier.Report, error) {
+				return report(rate, tt.limit), nil
+			}
+
+			res := Search(context.Background(), tt.cfg, run, discard{})
+
+			if got := rates(res); !reflect.DeepEqual(got, tt.rates) {
+				t.Errorf("probed %v, want %v", got, tt.rates)
+			}
+			if res.Capacity != tt.capacity || res.Ceiling != tt.ceiling {
+				t.Errorf("got capacity %v and ceiling %v, want %v and %v", res.Capacity, res.Ceiling, tt.capacity, tt.ceiling)
+			}
+			if res.Partitions != 2 {
+				t.Errorf("got %d partitions, want 2", res.Partitions)
+			}
+			if tt.capacity > 0 && res.BytesPerSec != tt.capacity*100 {
+				t.Errorf("got %v bytes/s, want the ones of the highest passing probe, %v", res.BytesPerSec, tt.capacity*100)
+			}
+		})
+	}
+}
+
+func TestSearchStopsWithTheContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	run := func(_ context.Context, rate float64) (*verifier.Report, error) {
+		if rate >= 400 {
+			cancel()
+		}
+		return report(rate, 1e9), nil
+	}
+
+	res := Search(ctx, cfg, run, discard{})
+	if got, want := rates(res), []float64{100, 200, 400}; !reflect.DeepEqual(got, want) {
+		t.Errorf("probed %v, want %v", got, want)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	const rate = 1000
+
+	tests := []struct {
+		name   string
+		change func(*verifier.Report)
+		err    error
+		passed bool
+	}{
+		{name: "passed", change: func(*verifier.Report) {}, passed: true},
+		{name: "run failed", change: func(*verifier.Report) {}, err: errors.New("timeout")},
+		{name: "records lost", change: func(r *verifier.Report) { r.Processed, r.LossPath = 999, "consumer" }},
+		{name: "errors", change: func(r *verifier.Report) { r.Errors = []string{"error"} }},
+		{name: "rate not reached", change: func(r *verifier.Report) { r.Throughput.RecordsPerSec = rate * 0.9 }},
+		{name: "rate nearly reached", change: func(r *verifier.Report) { r.Throughput.RecordsPerSec = rate * 0.96 }, passed: true},
+		{name: "p99 too high", change: func(r *verifier.Report) { r.Latency.P99 = 2 * time.Second }},
+		{name: "lag too high", change: func(r *verifier.Report) { r.MaxLag = 2 * rate }},
+		{name: "lag within a second", change: func(r *verifier.Report) { r.MaxLag = rate }, passed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := report(rate, rate)
+			tt.change(r)
+
+			p := evaluate(cfg, rate, r, 0, tt.err)
+			if p.Passed != tt.passed {
+				t.Errorf("passed: %t, want %t (%s)", p.Passed, tt.passed, p.Reason)
+			}
+			if !p.Passed && p.Reason == "" {
+				t.Error("failed without a reason")
+			}
+		})
+	}
+
+	if p := evaluate(cfg, rate, nil, 0, errors.New("not started")); p.Passed || p.Reason != "not started" {
+		t.Errorf("evaluating a run without report: got %+v", p)
+	}
+}
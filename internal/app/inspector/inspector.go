@@ -0,0 +1,111 @@
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/app/verifier"
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+)
+
+var errLimitReached = errors.New("limit reached")
+
+type Tailer interface {
+	Tail(context.Context, func(consumer.Record) error) error
+}
+
+type Logger interface {
+	Info(string)
+	Infof(string, ...any)
+	Error(string)
+	Errorf(string, ...any)
+}
+
+type InspectorConfig struct {
+	State string // only events in this state
+	ID    string // only events whose ID contains this
+	Limit int    // stop after this many matching records, unlimited when 0
+
+	Codec codec.Codec // decodes the record values
+}
+
+// Line is what is printed for every matching record. Event is nil when the
+// value could not be decoded, in which case Value holds it as is.
+type Line struct {
+	Partition int32           `json:"partition"`
+	Offset    int64           `json:"offset"`
+	Timestamp time.Time       `json:"timestamp"`
+	Key       string          `json:"key,omitempty"`
+	Event     *verifier.Event `json:"event,omitempty"`
+	Value     string          `json:"value,omitempty"`
+}
+
+// Inspector tails a topic and prints the records matching the filters as JSON
+// lines.
+type Inspector struct {
+	cfg    InspectorConfig
+	tailer Tailer
+	out    io.Writer
+	logger Logger
+}
+
+func New(cfg InspectorConfig, t Tailer, out io.Writer, l Logger) *Inspector {
+	return &Inspector{cfg: cfg, tailer: t, out: out, logger: l}
+}
+
+func (i *Inspector) Inspect(ctx context.Context) error {
+	enc := json.NewEncoder(i.out)
+	printed := 0
+
+	err := i.tailer.Tail(ctx, func(r consumer.Record) error {
+		line := Line{Partition: r.Partition, Offset: r.Offset, Timestamp: r.Timestamp, Key: string(r.Key)}
+
+		if e, err := i.cfg.Codec.Decode(r.Value); err == nil {
+			line.Event = &e
+		} else {
+			line.Value = string(r.Value)
+		}
+
+		if !i.matches(line) {
+			return nil
+		}
+
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+
+		printed++
+		if i.cfg.Limit > 0 && printed >= i.cfg.Limit {
+			return errLimitReached
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errLimitReached) {
+		return err
+	}
+
+	i.logger.Infof("%d records matched", printed)
+
+	return nil
+}
+
+func (i *Inspector) matches(line Line) bool {
+	if i.cfg.State == "" && i.cfg.ID == "" {
+		return true
+	}
+	if line.Event == nil {
+		return false
+	}
+
+	if i.cfg.State != "" && line.Event.State != i.cfg.State {
+		return false
+	}
+
+	return i.cfg.ID == "" || strings.Contains(line.Event.ID, i.cfg.ID)
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package matrix
+
+import "time"
+
+// CPUTime is not measured on this platform.
+func CPUTime() time.Duration {
+	return 0
+}
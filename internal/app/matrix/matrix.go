@@ -0,0 +1,195 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/app/verifier"
+)
+
+// Result sums up one verification run of the matrix.
+type Result struct {
+	Name     string    `json:"name"`
+	Settings []Setting `json:"settings"`
+
+	Generated int64  `json:"generated"`
+	Processed int64  `json:"processed"`
+	LossPath  string `json:"loss_path"`
+	Errors    int    `json:"errors"`
+	Correct   bool   `json:"correct"`
+
+	RecordsPerSec float64       `json:"records_per_sec"`
+	BytesPerSec   float64       `json:"bytes_per_sec"`
+	CPU           time.Duration `json:"cpu"` // user and system time of the whole process during the run
+	Compression   float64       `json:"compression_ratio"`
+
+	LatencyP50 time.Duration `json:"latency_p50"`
+	LatencyP90 time.Duration `json:"latency_p90"`
+	LatencyP99 time.Duration `json:"latency_p99"`
+	LatencyMax time.Duration `json:"latency_max"`
+
+	Err string `json:"error,omitempty"` // why the run could not complete
+}
+
+// Summarize builds the result of a run out of its report, nil when the run
+// failed before reporting.
+func Summarize(settings []Setting, r *verifier.Report, cpu time.Duration, err error) Result {
+	res := Result{Name: Name(settings), Settings: settings, CPU: cpu}
+	if err != nil {
+		res.Err = err.Error()
+	}
+	if r == nil {
+		return res
+	}
+
+	res.Generated, res.Processed = r.Generated, r.Processed
+	res.LossPath, res.Errors = r.LossPath, len(r.Errors)+int(r.ErrorsDropped)
+	res.Correct = err == nil && r.LossPath == "none" && r.Processed == r.Generated && res.Errors == 0 &&
+		(r.Checksums == nil || r.Checksums.Corrupted == 0)
+
+	if r.Throughput != nil {
+		res.RecordsPerSec, res.BytesPerSec = r.Throughput.RecordsPerSec, r.Throughput.BytesPerSec
+	}
+
+	// the ratio of every broker, weighted by the compressed bytes sent to it
+	var compressed, uncompressed float64
+	for _, b := range r.Brokers {
+		if b.ProducedBytes > 0 {
+			compressed += float64(b.ProducedBytes)
+			uncompressed += float64(b.ProducedBytes) * b.ProduceCompression
+		}
+	}
+	if compressed > 0 {
+		res.Compression = uncompressed / compressed
+	}
+
+	res.LatencyP50, res.LatencyP90 = r.Latency.P50, r.Latency.P90
+	res.LatencyP99, res.LatencyMax = r.Latency.P99, r.Latency.Max
+
+	return res
+}
+
+var columns = []string{"records/s", "bytes/s", "cpu", "compression", "p50", "p90", "p99", "max", "generated", "processed", "correct"}
+
+// header names a column per setting of the runs, then one per result.
+func header(results []Result) []string {
+	var h []string
+	if len(results) > 0 {
+		for _, s := range results[0].Settings {
+			h = append(h, s.Name)
+		}
+	}
+	return append(h, columns...)
+}
+
+func (r Result) row() []string {
+	correct := fmt.Sprint(r.Correct)
+	if r.Err != "" {
+		correct = "error: " + r.Err
+	}
+
+	var row []string
+	for _, s := range r.Settings {
+		row = append(row, s.Value)
+	}
+
+	return append(row,
+		fmt.Sprintf("%.0f", r.RecordsPerSec),
+		fmt.Sprintf("%.0f", r.BytesPerSec),
+		r.CPU.Round(time.Millisecond).String(),
+		fmt.Sprintf("%.2fx", r.Compression),
+		r.LatencyP50.String(),
+		r.LatencyP90.String(),
+		r.LatencyP99.String(),
+		r.LatencyMax.String(),
+		fmt.Sprint(r.Generated),
+		fmt.Sprint(r.Processed),
+		correct,
+	)
+}
+
+// Lines returns the results as an aligned text table, one line per run after
+// the header.
+func Lines(results []Result) []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+
+	for _, row := range append([][]string{header(results)}, rows(results)...) {
+		for _, cell := range row {
+			fmt.Fprintf(w, "%s\t", cell)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+func rows(results []Result) [][]string {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, r.row())
+	}
+	return rows
+}
+
+// WriteCSV writes the results to path as CSV, unless it is empty.
+func WriteCSV(path string, results []Result) error {
+	if path == "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(header(results))
+	w.WriteAll(rows(results))
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// WriteMarkdown writes the results to path as a Markdown table, unless it is
+// empty.
+func WriteMarkdown(path string, results []Result) error {
+	if path == "" {
+		return nil
+	}
+
+	h := header(results)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "| %s |\n", strings.Join(h, " | "))
+	fmt.Fprintf(&buf, "|%s\n", strings.Repeat("---|", len(h)))
+	for _, row := range rows(results) {
+		for i := range row {
+			row[i] = strings.ReplaceAll(row[i], "|", "\\|")
+		}
+		fmt.Fprintf(&buf, "| %s |\n", strings.Join(row, " | "))
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// WriteJSON writes the results to path, unless it is empty.
+func WriteJSON(path string, results []Result) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Runs []Result `json:"runs"`
+	}{results}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,76 @@
+package matrix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dimension is a setting and the values the runs of the matrix take for it.
+type Dimension struct {
+	Name   string
+	Values []string
+}
+
+// Setting is the value a run takes for a dimension.
+type Setting struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Parse parses dimensions separated by semicolons, each being a name and its
+// comma separated values, e.g. linger=0ms,5ms;acks=all,leader.
+func Parse(s string) ([]Dimension, error) {
+	var dims []Dimension
+
+	for _, part := range strings.Split(s, ";") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+
+		name, values, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid matrix dimension %q, expected name=value,...", part)
+		}
+
+		d := Dimension{Name: strings.TrimSpace(name)}
+		for _, v := range strings.Split(values, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				d.Values = append(d.Values, v)
+			}
+		}
+		if len(d.Values) == 0 {
+			return nil, fmt.Errorf("matrix dimension %s has no values", d.Name)
+		}
+
+		dims = append(dims, d)
+	}
+
+	return dims, nil
+}
+
+// Combinations returns every combination of the values of the dimensions,
+// the last dimension varying fastest.
+func Combinations(dims []Dimension) [][]Setting {
+	combinations := [][]Setting{nil}
+
+	for _, d := range dims {
+		next := make([][]Setting, 0, len(combinations)*len(d.Values))
+		for _, c := range combinations {
+			for _, v := range d.Values {
+				next = append(next, append(append([]Setting{}, c...), Setting{Name: d.Name, Value: v}))
+			}
+		}
+		combinations = next
+	}
+
+	return combinations
+}
+
+// Name names a run after its settings, e.g. linger=5ms acks=all.
+func Name(settings []Setting) string {
+	parts := make([]string, 0, len(settings))
+	for _, s := range settings {
+		parts = append(parts, s.Name+"="+s.Value)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,85 @@
+package matrix
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []Dimension
+	}{
+		{"", nil},
+		{"linger=5ms", []Dimension{{"linger", []string{"5ms"}}}},
+		{
+			"linger=0ms,5ms;acks=all,leader",
+			[]Dimension{{"linger", []string{"0ms", "5ms"}}, {"acks", []string{"all", "leader"}}},
+		},
+		{
+			" linger = 0ms , 5ms ; ; acks=all,, ;",
+			[]Dimension{{"linger", []string{"0ms", "5ms"}}, {"acks", []string{"all"}}},
+		},
+		{"compression=zstd:3,gzip:9", []Dimension{{"compression", []string{"zstd:3", "gzip:9"}}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.s)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", tt.s, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsing %q: got %+v, want %+v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{"linger", "linger=", "linger=5ms;acks", "acks= , ;"} {
+		if dims, err := Parse(s); err == nil {
+			t.Errorf("parsing %q: got %+v, want an error", s, dims)
+		}
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	tests := []struct {
+		dims []Dimension
+		want []string
+	}{
+		{nil, []string{""}}, // a single run, with no setting
+		{[]Dimension{{"acks", []string{"all", "leader"}}}, []string{"acks=all", "acks=leader"}},
+		{
+			[]Dimension{{"linger", []string{"0ms", "5ms"}}, {"acks", []string{"all", "leader", "none"}}},
+			[]string{
+				"linger=0ms acks=all", "linger=0ms acks=leader", "linger=0ms acks=none",
+				"linger=5ms acks=all", "linger=5ms acks=leader", "linger=5ms acks=none",
+			},
+		},
+		{
+			[]Dimension{{"a", []string{"1", "2"}}, {"b", []string{"1"}}, {"c", []string{"1", "2"}}},
+			[]string{"a=1 b=1 c=1", "a=1 b=1 c=2", "a=2 b=1 c=1", "a=2 b=1 c=2"},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, settings := range Combinations(tt.dims) {
+			got = append(got, Name(settings))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("combinations of %+v: got %q, want %q", tt.dims, got, tt.want)
+		}
+	}
+}
+
+func TestCombinationsDontShareSettings(t *testing.T) {
+	combinations := Combinations([]Dimension{{"a", []string{"1", "2"}}, {"b", []string{"1", "2"}}, {"c", []string{"1", "2"}}})
+
+	combinations[0][2].Value = "changed"
+	for _, settings := range combinations[1:] {
+		for _, s := range settings {
+			if s.Value == "changed" {
+				t.Fatalf("changing a setting of the first combination changed %s", Name(settings))
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package verifier
+
+import (
+	"context"
+	"maps"
+	"sync/atomic"
+	"time"
+)
+
+// How long and how often the end offsets are snapshotted again after
+// producing, until they cover every acknowledged record or stop moving: with
+// acks of leader or none, the high watermark only advances once the followers
+// replicated the records.
+const (
+	settleTimeout  = 10 * time.Second
+	settleInterval = 500 * time.Millisecond
+)
+
+// PartitionAccounting holds the high watermarks of a partition taken right
+// before and right after producing.
+type PartitionAccounting struct {
+	Before   int64 `json:"before"`
+	After    int64 `json:"after"`
+	Appended int64 `json:"appended"`
+}
+
+// Accounting compares the number of records the broker appended to the topic
+// while producing with the number of records the producer got acknowledged.
+type Accounting struct {
+	Produced   int64                         `json:"produced"`
+	Appended   int64                         `json:"appended"`
+	Missing    int64                         `json:"missing"` // acknowledged records the broker didn't append
+	Extra      int64                         `json:"extra"`   // records appended beyond the acknowledged ones, e.g. retried or from other producers
+	Partitions map[int32]PartitionAccounting `json:"partitions"`
+	Match      bool                          `json:"match"`
+}
+
+func (v *Verifier) snapshotEndOffsets() map[int32]int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	offsets, err := v.source.EndOffsets(ctx)
+	if err != nil {
+		v.logger.Errorf("snapshotting end offsets: %v", err)
+		v.addUnexpectedError(err.Error())
+		return nil
+	}
+
+	return offsets
+}
+
+// settledEndOffsets snapshots the end offsets after producing, again until
+// they cover every acknowledged record or stop moving, so that records still
+// being replicated aren't taken for lost.
+func (v *Verifier) settledEndOffsets(before map[int32]int64) map[int32]int64 {
+	after := v.snapshotEndOffsets()
+
+	for deadline := time.Now().Add(settleTimeout); before != nil && after != nil && time.Now().Before(deadline); {
+		if appended(before, after) >= atomic.LoadInt64(&v.counts.totalGenerated) {
+			break
+		}
+
+		time.Sleep(settleInterval)
+
+		next := v.snapshotEndOffsets()
+		if next == nil || maps.Equal(next, after) {
+			break
+		}
+		after = next
+	}
+
+	return after
+}
+
+func appended(before, after map[int32]int64) int64 {
+	var n int64
+	for p, end := range after {
+		n += end - before[p]
+	}
+	return n
+}
+
+// account builds the broker-side accounting out of the watermarks taken before
+// and after producing. Records appended by other producers during the run are
+// indistinguishable from ours and will show up as a mismatch.
+func (v *Verifier) account(before, after map[int32]int64) *Accounting {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	acc := &Accounting{
+		Produced:   atomic.LoadInt64(&v.counts.totalGenerated),
+		Partitions: make(map[int32]PartitionAccounting, len(after)),
+	}
+
+	for p, end := range after {
+		pa := PartitionAccounting{Before: before[p], After: end, Appended: end - before[p]}
+		acc.Partitions[p] = pa
+		acc.Appended += pa.Appended
+	}
+
+	acc.Missing = max(acc.Produced-acc.Appended, 0)
+	acc.Extra = max(acc.Appended-acc.Produced, 0)
+	acc.Match = acc.Appended == acc.Produced
+
+	return acc
+}
+
+// lossPath tells whether missing records were lost before reaching the broker
+// (write path) or after being appended to the topic (read path). The read path
+// is only considered when the run consumed what it produced. Extra records
+// appended are no loss.
+func (r *Report) lossPath(consumed bool) string {
+	switch {
+	case r.Accounting != nil && r.Accounting.Missing > 0:
+		return "write"
+	case !consumed:
+		return "none"
+	case r.Processed < r.Generated && r.Accounting == nil:
+		return "unknown"
+	case r.Processed < r.Generated:
+		return "read"
+	default:
+		return "none"
+	}
+}
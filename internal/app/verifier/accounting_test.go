@@ -0,0 +1,49 @@
+package verifier
+
+import (
+	"testing"
+)
+
+func TestAccountAndLossPath(t *testing.T) {
+	tests := []struct {
+		name           string
+		produced       int64
+		before, after  map[int32]int64
+		processed      int64
+		missing, extra int64
+		consumed       bool
+		lossPath       string
+	}{
+		{"all appended and read", 10, map[int32]int64{0: 5, 1: 0}, map[int32]int64{0: 10, 1: 5}, 10, 0, 0, true, "none"},
+		{"lost writing", 10, map[int32]int64{0: 0}, map[int32]int64{0: 8}, 8, 2, 0, true, "write"},
+		{"lost reading", 10, map[int32]int64{0: 0}, map[int32]int64{0: 10}, 9, 0, 0, true, "read"},
+		{"retried", 10, map[int32]int64{0: 0}, map[int32]int64{0: 12}, 10, 0, 2, true, "none"},
+		{"retried and lost reading", 10, map[int32]int64{0: 0}, map[int32]int64{0: 12}, 9, 0, 2, true, "read"},
+		{"new partition", 10, map[int32]int64{0: 0}, map[int32]int64{0: 6, 1: 4}, 10, 0, 0, true, "none"},
+		{"only produced", 10, map[int32]int64{0: 0}, map[int32]int64{0: 10}, 0, 0, 0, false, "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Verifier{}
+			v.counts.totalGenerated = tt.produced
+
+			acc := v.account(tt.before, tt.after)
+			if acc.Missing != tt.missing || acc.Extra != tt.extra || acc.Match != (tt.missing == 0 && tt.extra == 0) {
+				t.Errorf("got %d missing, %d extra and match %t, want %d and %d", acc.Missing, acc.Extra, acc.Match, tt.missing, tt.extra)
+			}
+
+			r := &Report{Generated: tt.produced, Processed: tt.processed, Accounting: acc}
+			if got := r.lossPath(tt.consumed); got != tt.lossPath {
+				t.Errorf("got loss path %s, want %s", got, tt.lossPath)
+			}
+		})
+	}
+
+	v := &Verifier{}
+	if acc := v.account(nil, map[int32]int64{0: 1}); acc != nil {
+		t.Errorf("accounting without snapshot before: got %+v", acc)
+	}
+	if got := (&Report{Generated: 10, Processed: 9}).lossPath(true); got != "unknown" {
+		t.Errorf("loss path without accounting: got %s, want unknown", got)
+	}
+}
@@ -0,0 +1,175 @@
+package verifier
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// Key strategies of the produced records.
+const (
+	KeyNone   = "none"   // no key, records are scattered by the partitioner
+	KeyID     = "id"     // the ID of the event
+	KeyHot    = "hot"    // one of a fixed set of hot keys
+	KeyRandom = "random" // a random key per record
+)
+
+// AffinityStats tell whether all the records of a key were written to and
+// consumed from a single partition, by a single partition consumer.
+type AffinityStats struct {
+	Strategy string `json:"strategy"`
+	Keys     int    `json:"keys"`
+
+	ProducedSplit int `json:"produced_split"` // keys written to several partitions
+	ConsumedSplit int `json:"consumed_split"` // keys consumed from several partitions
+	Moved         int `json:"moved"`          // keys consumed from another partition than written to
+	WorkerSplit   int `json:"worker_split"`   // keys consumed by several partition consumers
+
+	Broken []string `json:"broken,omitempty"` // some of the keys above
+
+	Untracked int64 `json:"untracked,omitempty"` // records whose key was not tracked once the key limit was reached
+}
+
+type keyState struct {
+	produced int32
+	consumed int32
+	worker   int64
+
+	producedSplit, consumedSplit, moved, workerSplit bool
+}
+
+type affinity struct {
+	strategy string
+	hotKeys  int
+	limit    int // keys tracked at most, unlimited when 0
+
+	mu        sync.Mutex
+	keys      map[string]*keyState
+	untracked int64
+}
+
+func newAffinity(strategy string, hotKeys, limit int) (*affinity, error) {
+	switch strategy {
+	case KeyNone, "":
+		return nil, nil
+	case KeyID, KeyRandom:
+	case KeyHot:
+		if hotKeys < 1 {
+			return nil, fmt.Errorf("at least one hot key is needed, got %d", hotKeys)
+		}
+	default:
+		return nil, fmt.Errorf("unknown key strategy %q", strategy)
+	}
+
+	return &affinity{strategy: strategy, hotKeys: hotKeys, limit: limit, keys: map[string]*keyState{}}, nil
+}
+
+// key returns the key of the record carrying the event with the given ID.
+func (a *affinity) key(id string) []byte {
+	if a == nil {
+		return nil
+	}
+
+	switch a.strategy {
+	case KeyID:
+		return []byte(id)
+	case KeyHot:
+		return []byte(fmt.Sprintf("hot-%d", rand.Intn(a.hotKeys)))
+	default:
+		return []byte(generateRandomID())
+	}
+}
+
+// state returns the state of the key, nil when the key is new and the limit
+// is reached.
+func (a *affinity) state(key string) *keyState {
+	s, ok := a.keys[key]
+	if !ok {
+		if a.limit > 0 && len(a.keys) >= a.limit {
+			return nil
+		}
+		s = &keyState{produced: -1, consumed: -1, worker: -1}
+		a.keys[key] = s
+	}
+	return s
+}
+
+func (a *affinity) produced(key []byte, partition int32) {
+	if a == nil || key == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := a.state(string(key))
+	if s == nil {
+		a.untracked++
+		return
+	}
+	if s.produced >= 0 && s.produced != partition {
+		s.producedSplit = true
+	}
+	// acks are stored once the whole batch is written, so the record may
+	// already have been consumed
+	if s.consumed >= 0 && s.consumed != partition {
+		s.moved = true
+	}
+	s.produced = partition
+}
+
+// consumed records the partition a key was consumed from and the partition
+// consumer, numbered by the verifier, that processed it.
+func (a *affinity) consumed(key []byte, partition int32, worker int64) {
+	if a == nil || key == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := a.state(string(key))
+	if s == nil {
+		return
+	}
+	if s.consumed >= 0 && s.consumed != partition {
+		s.consumedSplit = true
+	}
+	if s.produced >= 0 && s.produced != partition {
+		s.moved = true
+	}
+	if s.worker >= 0 && s.worker != worker {
+		s.workerSplit = true
+	}
+	s.consumed, s.worker = partition, worker
+}
+
+func (a *affinity) stats() *AffinityStats {
+	if a == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := &AffinityStats{Strategy: a.strategy, Keys: len(a.keys), Untracked: a.untracked}
+	for key, ks := range a.keys {
+		if ks.producedSplit {
+			s.ProducedSplit++
+		}
+		if ks.consumedSplit {
+			s.ConsumedSplit++
+		}
+		if ks.moved {
+			s.Moved++
+		}
+		if ks.workerSplit {
+			s.WorkerSplit++
+		}
+		if ks.producedSplit || ks.consumedSplit || ks.moved || ks.workerSplit {
+			s.Broken = appendID(s.Broken, key)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,129 @@
+package verifier
+
+import (
+	"reflect"
+	"testing"
+)
+
+// affinityOp records a key produced to a partition when worker is -1, or
+// consumed from it by the worker otherwise.
+type affinityOp struct {
+	key       string
+	partition int32
+	worker    int64
+}
+
+func TestAffinity(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []affinityOp
+		want AffinityStats
+	}{
+		{
+			name: "kept together",
+			ops:  []affinityOp{{"a", 0, -1}, {"a", 0, 1}, {"a", 0, -1}, {"a", 0, 1}, {"b", 1, -1}, {"b", 1, 2}},
+			want: AffinityStats{Keys: 2},
+		},
+		{
+			name: "produced split",
+			ops:  []affinityOp{{"a", 0, -1}, {"a", 1, -1}},
+			want: AffinityStats{Keys: 1, ProducedSplit: 1, Broken: []string{"a"}},
+		},
+		{
+			name: "consumed split",
+			ops:  []affinityOp{{"a", 0, 1}, {"a", 1, 1}},
+			want: AffinityStats{Keys: 1, ConsumedSplit: 1, Broken: []string{"a"}},
+		},
+		{
+			name: "moved, produced first",
+			ops:  []affinityOp{{"a", 0, -1}, {"a", 1, 1}},
+			want: AffinityStats{Keys: 1, Moved: 1, Broken: []string{"a"}},
+		},
+		{
+			name: "moved, consumed before the ack is stored",
+			ops:  []affinityOp{{"a", 1, 1}, {"a", 0, -1}},
+			want: AffinityStats{Keys: 1, Moved: 1, Broken: []string{"a"}},
+		},
+		{
+			name: "worker split",
+			ops:  []affinityOp{{"a", 0, -1}, {"a", 0, 1}, {"a", 0, 2}},
+			want: AffinityStats{Keys: 1, WorkerSplit: 1, Broken: []string{"a"}},
+		},
+		{
+			name: "worker split, consumed before the acks are stored",
+			ops:  []affinityOp{{"a", 0, 1}, {"a", 0, 2}, {"a", 0, -1}, {"a", 0, -1}},
+			want: AffinityStats{Keys: 1, WorkerSplit: 1, Broken: []string{"a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newAffinity(KeyID, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, op := range tt.ops {
+				if op.worker < 0 {
+					a.produced([]byte(op.key), op.partition)
+				} else {
+					a.consumed([]byte(op.key), op.partition, op.worker)
+				}
+			}
+
+			tt.want.Strategy = KeyID
+			if got := a.stats(); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAffinityLimit(t *testing.T) {
+	a, err := newAffinity(KeyHot, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.produced([]byte("a"), 0)
+	a.produced([]byte("b"), 0)
+	a.produced([]byte("c"), 0) // beyond the limit
+	a.produced([]byte("c"), 1)
+	a.produced([]byte("a"), 1) // tracked keys are still checked
+	a.consumed([]byte("c"), 0, 1)
+
+	s := a.stats()
+	if s.Keys != 2 || s.Untracked != 2 || s.ProducedSplit != 1 {
+		t.Errorf("got %d keys, %d records untracked and %d split, want 2, 2 and 1", s.Keys, s.Untracked, s.ProducedSplit)
+	}
+}
+
+func TestNewAffinity(t *testing.T) {
+	tests := []struct {
+		strategy string
+		hotKeys  int
+		none     bool
+		wantErr  bool
+	}{
+		{strategy: KeyNone, none: true},
+		{strategy: "", none: true},
+		{strategy: KeyID},
+		{strategy: KeyRandom},
+		{strategy: KeyHot, hotKeys: 3},
+		{strategy: KeyHot, wantErr: true},
+		{strategy: "round-robin", wantErr: true},
+	}
+	for _, tt := range tests {
+		a, err := newAffinity(tt.strategy, tt.hotKeys, 0)
+		if (err != nil) != tt.wantErr || (a == nil) != (tt.none || tt.wantErr) {
+			t.Errorf("%q: got %v and error %v", tt.strategy, a, err)
+		}
+	}
+
+	// a nil affinity neither keys records nor reports
+	var a *affinity
+	if a.key("id") != nil || a.stats() != nil {
+		t.Error("a nil affinity keyed or reported")
+	}
+	a.produced([]byte("a"), 0)
+	a.consumed([]byte("a"), 0, 0)
+}
@@ -0,0 +1,235 @@
+package verifier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+)
+
+// CanaryStats are the SLO metrics of a canary run: availability is the share
+// of probes successfully produced, latency is measured end to end from
+// produce to consume, and a probe is lost when it is not consumed within the
+// canary timeout.
+type CanaryStats struct {
+	Uptime        time.Duration `json:"uptime"`
+	Partitions    int           `json:"partitions"`
+	Produced      int64         `json:"produced"`
+	ProduceErrors int64         `json:"produce_errors"`
+	Availability  float64       `json:"availability"`
+	Received      int64         `json:"received"`
+	Lost          int64         `json:"lost"`
+	Pending       int           `json:"pending"`
+	Latency       stats.Summary `json:"latency"`
+}
+
+type canary struct {
+	mu sync.Mutex
+
+	started    time.Time
+	partitions int
+	pending    map[string]time.Time
+
+	attempts int64
+	errors   int64
+	received int64
+	lost     int64
+
+	latency *stats.Histogram
+}
+
+// Canary keeps producing one small probe event to every partition each
+// CanaryInterval and consuming them back, until the context is done. It is
+// meant to be left running next to a cluster.
+func (v *Verifier) Canary(ctx context.Context) error {
+	v.logger.Info("starting canary")
+
+	v.canary = &canary{started: time.Now(), pending: map[string]time.Time{}, latency: stats.NewHistogram()}
+
+	if err := v.consumer.Consume(v.canaryConsumer); err != nil {
+		v.logger.Error("starting the consumer")
+		return err
+	}
+
+	probe := time.NewTicker(v.cfg.CanaryInterval)
+	defer probe.Stop()
+
+	report := time.NewTicker(v.cfg.CanaryReportInterval)
+	defer report.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			v.logger.Info("canary stopped")
+			return v.canaryReport()
+		case <-probe.C:
+			v.probe(ctx)
+			v.expireProbes()
+		case <-report.C:
+			if err := v.canaryReport(); err != nil {
+				v.logger.Errorf("writing canary report: %v", err)
+			}
+		}
+	}
+}
+
+// probe produces one event to every partition of the topic.
+func (v *Verifier) probe(ctx context.Context) {
+	actx, cancel := context.WithTimeout(ctx, v.cfg.CanaryInterval)
+	ends, err := v.source.EndOffsets(actx)
+	cancel()
+	if err != nil {
+		v.logger.Errorf("listing canary partitions: %v", err)
+		return
+	}
+
+	c := v.canary
+	c.mu.Lock()
+	c.partitions = len(ends)
+	c.mu.Unlock()
+
+	// every probe of a round is sent at once, so a slow partition delays
+	// neither the others nor the next round
+	var wg sync.WaitGroup
+	for partition := range ends {
+		wg.Add(1)
+		go func(partition int32) {
+			defer wg.Done()
+			v.probePartition(ctx, partition)
+		}(partition)
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	availability := c.availability()
+	c.mu.Unlock()
+
+	v.logger.RecordAvailability(availability)
+}
+
+// probePartition produces one event to the partition.
+func (v *Verifier) probePartition(ctx context.Context, partition int32) {
+	c := v.canary
+	event := Event{ID: generateRandomID(), State: Success, SentAt: time.Now().UnixNano()}
+
+	payload, err := v.encode(event)
+	if err != nil {
+		v.addUnexpectedError(err.Error())
+		return
+	}
+
+	c.mu.Lock()
+	c.attempts++
+	c.pending[event.ID] = time.Now()
+	c.mu.Unlock()
+
+	pctx, cancel := context.WithTimeout(ctx, v.cfg.CanaryTimeout)
+	err = v.producer.ProduceTo(pctx, partition, payload)
+	cancel()
+
+	if err != nil {
+		v.logger.Errorf("producing canary probe to partition %d: %v", partition, err)
+		v.logger.RecordProduceError()
+
+		c.mu.Lock()
+		c.errors++
+		delete(c.pending, event.ID)
+		c.mu.Unlock()
+		return
+	}
+
+	v.logger.RecordSent(event.State)
+}
+
+// availability is the share of probes successfully produced so far. It must
+// be called with the lock held.
+func (c *canary) availability() float64 {
+	if c.attempts == 0 {
+		return 0
+	}
+	return float64(c.attempts-c.errors) / float64(c.attempts)
+}
+
+// expireProbes counts as lost every probe not consumed within the timeout.
+func (v *Verifier) expireProbes() {
+	c := v.canary
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, sent := range c.pending {
+		if time.Since(sent) > v.cfg.CanaryTimeout {
+			c.lost++
+			delete(c.pending, id)
+			v.logger.Errorf("canary probe %s lost", id)
+			v.logger.RecordProbeLost()
+		}
+	}
+}
+
+func (v *Verifier) canaryConsumer(res chan []consumer.Record) {
+	go func() {
+		v.logger.AddedProcessor()
+		defer v.logger.RemovedProcessor()
+
+		c := v.canary
+
+		for msgs := range res {
+			for _, msg := range msgs {
+				e, err := v.decode(msg.Value)
+				if err != nil {
+					v.addUnexpectedError(err.Error())
+					continue
+				}
+
+				c.mu.Lock()
+				_, ok := c.pending[e.ID]
+				if ok {
+					delete(c.pending, e.ID)
+					c.received++
+				}
+				c.mu.Unlock()
+
+				if !ok {
+					// probes from previous runs or already counted as lost
+					continue
+				}
+
+				d := time.Since(time.Unix(0, e.SentAt))
+				c.latency.Observe(d)
+				v.logger.RecordLatency(d)
+				v.logger.RecordProcessed(e.State)
+			}
+		}
+	}()
+}
+
+func (v *Verifier) canaryStats() *CanaryStats {
+	c := v.canary
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := &CanaryStats{
+		Uptime:        time.Since(c.started),
+		Partitions:    c.partitions,
+		Produced:      c.attempts,
+		ProduceErrors: c.errors,
+		Received:      c.received,
+		Lost:          c.lost,
+		Pending:       len(c.pending),
+		Latency:       c.latency.Summary(),
+		Availability:  c.availability(),
+	}
+
+	return s
+}
+
+func (v *Verifier) canaryReport() error {
+	s := v.canaryStats()
+
+	v.logger.Infof("canary %s: availability %.4f, %d received, %d lost, latency p50 %s p99 %s",
+		s.Uptime.Round(time.Second), s.Availability, s.Received, s.Lost, s.Latency.P50, s.Latency.P99)
+
+	return v.writeJSON(s)
+}
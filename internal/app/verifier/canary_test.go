@@ -0,0 +1,168 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+)
+
+// probeProducer keeps the probes produced to every partition, failing the
+// ones to the given partitions.
+type probeProducer struct {
+	Producer
+
+	failing map[int32]bool
+
+	mu     sync.Mutex
+	probes map[int32][][]byte
+}
+
+func (p *probeProducer) ProduceTo(_ context.Context, partition int32, payload []byte) error {
+	if p.failing[partition] {
+		return errors.New("not enough replicas")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.probes[partition] = append(p.probes[partition], payload)
+	return nil
+}
+
+type partitions int
+
+func (n partitions) EndOffsets(context.Context) (map[int32]int64, error) {
+	ends := map[int32]int64{}
+	for p := range int32(n) {
+		ends[p] = 0
+	}
+	return ends, nil
+}
+
+func (partitions) CommittedOffsets(context.Context) (map[int32]int64, error) {
+	return nil, nil
+}
+
+// doneLogger tells when a processor returned.
+type doneLogger struct {
+	discard
+	done chan struct{}
+}
+
+func (l doneLogger) RemovedProcessor() { close(l.done) }
+
+func newTestCanary(cfg VerifierConfig) *Verifier {
+	v := newTestVerifier(cfg)
+	v.codec, _ = codec.New(codec.JSON)
+	v.canary = &canary{started: time.Now(), pending: map[string]time.Time{}, latency: stats.NewHistogram()}
+	return v
+}
+
+func TestCanaryProbe(t *testing.T) {
+	v := newTestCanary(VerifierConfig{CanaryInterval: time.Second, CanaryTimeout: time.Second})
+	p := &probeProducer{failing: map[int32]bool{1: true}, probes: map[int32][][]byte{}}
+	v.producer, v.source = p, partitions(3)
+
+	v.probe(context.Background())
+	v.probe(context.Background())
+
+	for partition := range int32(3) {
+		want := 2
+		if partition == 1 {
+			want = 0
+		}
+		if got := len(p.probes[partition]); got != want {
+			t.Errorf("got %d probes produced to partition %d, want %d", got, partition, want)
+		}
+	}
+
+	s := v.canaryStats()
+	if s.Partitions != 3 || s.Produced != 6 || s.ProduceErrors != 2 || s.Pending != 4 {
+		t.Errorf("got %d partitions, %d produced, %d errors and %d pending, want 3, 6, 2 and 4", s.Partitions, s.Produced, s.ProduceErrors, s.Pending)
+	}
+	if want := 4.0 / 6; s.Availability != want {
+		t.Errorf("got an availability of %v, want %v", s.Availability, want)
+	}
+}
+
+func TestCanaryAvailability(t *testing.T) {
+	tests := []struct {
+		attempts, errors int64
+		want             float64
+	}{
+		{0, 0, 0}, // nothing produced yet
+		{10, 0, 1},
+		{10, 1, 0.9},
+		{10, 10, 0},
+	}
+	for _, tt := range tests {
+		c := &canary{attempts: tt.attempts, errors: tt.errors}
+		if got := c.availability(); got != tt.want {
+			t.Errorf("%d attempts, %d errors: got %v, want %v", tt.attempts, tt.errors, got, tt.want)
+		}
+	}
+}
+
+func TestExpireProbes(t *testing.T) {
+	const timeout = time.Minute
+
+	v := newTestCanary(VerifierConfig{CanaryTimeout: timeout})
+	now := time.Now()
+	v.canary.pending = map[string]time.Time{
+		"old":    now.Add(-2 * timeout),
+		"older":  now.Add(-time.Hour),
+		"recent": now.Add(-timeout / 2),
+	}
+
+	v.expireProbes()
+	v.expireProbes() // lost probes are counted once
+
+	s := v.canaryStats()
+	if s.Lost != 2 || s.Pending != 1 {
+		t.Errorf("got %d lost and %d pending, want 2 and 1", s.Lost, s.Pending)
+	}
+	if _, ok := v.canary.pending["recent"]; !ok {
+		t.Error("the probe sent within the timeout expired")
+	}
+}
+
+func TestCanaryConsumer(t *testing.T) {
+	v := newTestCanary(VerifierConfig{CanaryTimeout: time.Minute})
+	l := doneLogger{done: make(chan struct{})}
+	v.logger = l
+
+	sent := time.Now().Add(-10 * time.Millisecond)
+	v.canary.pending = map[string]time.Time{"a": sent, "b": sent, "c": sent}
+
+	record := func(id string) consumer.Record {
+		b, err := v.encode(Event{ID: id, State: Success, SentAt: sent.UnixNano()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return consumer.Record{Value: b}
+	}
+
+	res := make(chan []consumer.Record)
+	v.canaryConsumer(res)
+	res <- []consumer.Record{record("a"), record("b")}
+	// redelivered, from a previous run and not even an event
+	res <- []consumer.Record{record("a"), record("old"), {Value: []byte("{")}}
+	close(res)
+	<-l.done
+
+	s := v.canaryStats()
+	if s.Received != 2 || s.Pending != 1 || s.Latency.Count != 2 {
+		t.Errorf("got %d received, %d pending and %d latencies, want 2, 1 and 2", s.Received, s.Pending, s.Latency.Count)
+	}
+	if s.Latency.Max < 10*time.Millisecond {
+		t.Errorf("got a max latency of %s, want at least the time since the probes were sent", s.Latency.Max)
+	}
+	if len(v.errList) != 1 {
+		t.Errorf("got errors %q, want the undecodable record only", v.errList)
+	}
+}
@@ -0,0 +1,89 @@
+package verifier
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+)
+
+// ChecksumStats count the consumed events whose checksum matched their body,
+// and the ones that were corrupted while still decoding fine, including the
+// ones that lost their checksum. Values that don't decode at all are counted
+// by the codec stats.
+type ChecksumStats struct {
+	Algorithm string `json:"algorithm"`
+	Verified  int64  `json:"verified"`
+	Corrupted int64  `json:"corrupted"`
+
+	CorruptedIDs []string `json:"corrupted_ids,omitempty"`
+}
+
+type checksums struct {
+	algorithm string
+
+	verified, corrupted int64
+
+	mu           sync.Mutex
+	corruptedIDs []string
+}
+
+func newChecksums(algorithm string) (*checksums, error) {
+	if algorithm == codec.NoChecksum || algorithm == "" {
+		return nil, nil
+	}
+
+	if _, err := codec.Sum(algorithm, Event{}); err != nil {
+		return nil, err
+	}
+
+	return &checksums{algorithm: algorithm}, nil
+}
+
+// sign sets the checksum of the event, keeping its length so that padding
+// computed beforehand still holds.
+func (c *checksums) sign(e *Event) {
+	if c == nil {
+		return
+	}
+
+	e.Checksum, _ = codec.Sum(c.algorithm, *e)
+}
+
+// check tells whether the event is intact, counting it. Every produced event
+// is signed, so one without checksum is corrupted too.
+func (c *checksums) check(e Event) bool {
+	if c == nil {
+		return true
+	}
+
+	if e.Checksum != "" && codec.Verify(e) {
+		atomic.AddInt64(&c.verified, 1)
+		return true
+	}
+
+	atomic.AddInt64(&c.corrupted, 1)
+
+	c.mu.Lock()
+	c.corruptedIDs = appendID(c.corruptedIDs, e.ID)
+	c.mu.Unlock()
+
+	return false
+}
+
+func (c *checksums) stats() *ChecksumStats {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &ChecksumStats{
+		Algorithm: c.algorithm,
+		Verified:  atomic.LoadInt64(&c.verified),
+		Corrupted: atomic.LoadInt64(&c.corrupted),
+
+		CorruptedIDs: c.corruptedIDs,
+	}
+}
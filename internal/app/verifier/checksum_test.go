@@ -0,0 +1,99 @@
+package verifier
+
+import (
+	"reflect"
+	"testing"
+
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+)
+
+func TestNewChecksums(t *testing.T) {
+	for _, algorithm := range []string{"", codec.NoChecksum} {
+		if c, err := newChecksums(algorithm); c != nil || err != nil {
+			t.Errorf("algorithm %q: got %+v and error %v, want neither", algorithm, c, err)
+		}
+	}
+	if _, err := newChecksums("md5"); err == nil {
+		t.Error("unknown algorithm: got no error")
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(*Event)
+		corrupted bool
+	}{
+		{name: "intact", change: func(*Event) {}},
+		{name: "state changed", change: func(e *Event) { e.State = Failed }, corrupted: true},
+		{name: "send time changed", change: func(e *Event) { e.SentAt++ }, corrupted: true},
+		{name: "padding truncated", change: func(e *Event) { e.Padding = e.Padding[1:] }, corrupted: true},
+		{name: "checksum missing", change: func(e *Event) { e.Checksum = "" }, corrupted: true},
+		{name: "checksum without algorithm", change: func(e *Event) { e.Checksum = e.Checksum[len(e.Checksum)-8:] }, corrupted: true},
+	}
+	for _, algorithm := range []string{codec.CRC32C, codec.SHA256} {
+		for _, tt := range tests {
+			t.Run(algorithm+"/"+tt.name, func(t *testing.T) {
+				c, err := newChecksums(algorithm)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				e := Event{ID: "a", State: Success, SentAt: 42, Padding: "xxxx"}
+				c.sign(&e)
+				tt.change(&e)
+
+				if ok := c.check(e); ok == tt.corrupted {
+					t.Errorf("got intact: %t, want %t", ok, !tt.corrupted)
+				}
+
+				want := &ChecksumStats{Algorithm: algorithm, Verified: 1}
+				if tt.corrupted {
+					want = &ChecksumStats{Algorithm: algorithm, Corrupted: 1, CorruptedIDs: []string{"a"}}
+				}
+				if got := c.stats(); !reflect.DeepEqual(got, want) {
+					t.Errorf("got %+v, want %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+// The checksum covers the padding, and survives the round trip through every
+// codec.
+func TestChecksumsOfPaddedEvents(t *testing.T) {
+	for _, algorithm := range []string{codec.CRC32C, codec.SHA256} {
+		for _, name := range []string{codec.JSON, codec.Avro, codec.Protobuf, codec.MessagePack} {
+			v := newTestVerifier(VerifierConfig{})
+			v.codec, _ = codec.New(name)
+			v.checksums, _ = newChecksums(algorithm)
+
+			e := Event{ID: "a", State: Success, SentAt: 42}
+			b, err := v.encodePadded(&e, 200)
+			if err != nil {
+				t.Fatalf("%s with %s: %v", name, algorithm, err)
+			}
+			if e.Padding == "" {
+				t.Fatalf("%s with %s: not padded", name, algorithm)
+			}
+
+			got, err := v.decode(b)
+			if err != nil {
+				t.Fatalf("%s with %s: %v", name, algorithm, err)
+			}
+			if !v.checksums.check(got) {
+				t.Errorf("%s with %s: the padded event %+v is corrupted", name, algorithm, got)
+			}
+		}
+	}
+}
+
+func TestNoChecksums(t *testing.T) {
+	var c *checksums
+
+	e := Event{ID: "a"}
+	c.sign(&e)
+	if e.Checksum != "" || !c.check(e) || c.stats() != nil {
+		t.Errorf("without algorithm: got checksum %q, stats %+v", e.Checksum, c.stats())
+	}
+}
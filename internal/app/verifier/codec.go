@@ -0,0 +1,93 @@
+package verifier
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CodecStats measure the serialization overhead of the configured codec.
+type CodecStats struct {
+	Name       string        `json:"name"`
+	Encoded    int64         `json:"encoded"`
+	Decoded    int64         `json:"decoded"`
+	Failed     int64         `json:"failed"`      // values that could not be decoded
+	EncodeTime time.Duration `json:"encode_time"` // mean per event
+	DecodeTime time.Duration `json:"decode_time"` // mean per event
+	MeanSize   float64       `json:"mean_size"`   // bytes per encoded event
+}
+
+type codecStats struct {
+	encoded, encodeNanos, bytes int64
+	decoded, decodeNanos        int64
+	failed                      int64
+}
+
+func (v *Verifier) encode(e Event) ([]byte, error) {
+	started := time.Now()
+	b, err := v.codec.Encode(e)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&v.codecStats.encodeNanos, int64(time.Since(started)))
+	atomic.AddInt64(&v.codecStats.encoded, 1)
+	atomic.AddInt64(&v.codecStats.bytes, int64(len(b)))
+
+	return b, nil
+}
+
+func (v *Verifier) decode(b []byte) (Event, error) {
+	started := time.Now()
+	e, err := v.codec.Decode(b)
+	if err != nil {
+		atomic.AddInt64(&v.codecStats.failed, 1)
+		return e, err
+	}
+
+	atomic.AddInt64(&v.codecStats.decodeNanos, int64(time.Since(started)))
+	atomic.AddInt64(&v.codecStats.decoded, 1)
+
+	return e, nil
+}
+
+// encodePadded encodes the event, padding it to the given size first. The
+// padding is sized with trial encodings, which are left out of the stats.
+func (v *Verifier) encodePadded(e *Event, size int) ([]byte, error) {
+	v.checksums.sign(e)
+
+	if size > 0 {
+		b, err := v.codec.Encode(*e)
+		if err == nil && len(b) < size {
+			e.Padding = strings.Repeat("x", size-len(b))
+
+			// the padding length itself takes a few bytes with most codecs
+			if b, err = v.codec.Encode(*e); err == nil && len(b) > size {
+				e.Padding = e.Padding[:max(0, len(e.Padding)-(len(b)-size))]
+			}
+			v.checksums.sign(e)
+		}
+	}
+
+	return v.encode(*e)
+}
+
+func (v *Verifier) codecSummary() *CodecStats {
+	c := &v.codecStats
+
+	s := &CodecStats{
+		Name:    v.codec.Name(),
+		Encoded: atomic.LoadInt64(&c.encoded),
+		Decoded: atomic.LoadInt64(&c.decoded),
+		Failed:  atomic.LoadInt64(&c.failed),
+	}
+	if s.Encoded > 0 {
+		s.EncodeTime = time.Duration(atomic.LoadInt64(&c.encodeNanos) / s.Encoded)
+		s.MeanSize = float64(atomic.LoadInt64(&c.bytes)) / float64(s.Encoded)
+	}
+	if s.Decoded > 0 {
+		s.DecodeTime = time.Duration(atomic.LoadInt64(&c.decodeNanos) / s.Decoded)
+	}
+
+	return s
+}
@@ -0,0 +1,83 @@
+package verifier
+
+import (
+	"context"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+)
+
+const (
+	CommitOK      = "ok"
+	CommitLagging = "lagging"
+	CommitAhead   = "ahead"
+)
+
+// PartitionCommit compares the offset committed by the group with the offset
+// following the last record delivered to the partition consumer.
+type PartitionCommit struct {
+	Committed int64  `json:"committed"`
+	Delivered int64  `json:"delivered"`
+	Status    string `json:"status"`
+}
+
+// CommitAudit summarizes the commits made during the run and how the final
+// committed offsets relate to what was actually processed.
+type CommitAudit struct {
+	Commits    int                       `json:"commits"`
+	Failed     []consumer.CommitAudit    `json:"failed"`
+	Partitions map[int32]PartitionCommit `json:"partitions"`
+}
+
+func (v *Verifier) auditCommits() *CommitAudit {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	committed, err := v.admin.CommittedOffsets(ctx)
+	if err != nil {
+		v.logger.Errorf("fetching committed offsets for the audit: %v", err)
+		v.addUnexpectedError(err.Error())
+		return nil
+	}
+
+	commits, failed := v.consumer.Commits()
+	delivered := v.consumer.Delivered()
+
+	return &CommitAudit{
+		Commits:    commits,
+		Failed:     failed,
+		Partitions: compareCommits(committed, delivered),
+	}
+}
+
+// compareCommits tells, for every partition committed or delivered, whether
+// the committed offset lags behind or is ahead of the delivered one.
+func compareCommits(committed, delivered map[int32]int64) map[int32]PartitionCommit {
+	partitions := map[int32]PartitionCommit{}
+
+	for p := range union(committed, delivered) {
+		pc := PartitionCommit{Committed: committed[p], Delivered: delivered[p], Status: CommitOK}
+
+		switch {
+		case pc.Committed < pc.Delivered:
+			pc.Status = CommitLagging
+		case pc.Committed > pc.Delivered:
+			pc.Status = CommitAhead
+		}
+
+		partitions[p] = pc
+	}
+
+	return partitions
+}
+
+func union(a, b map[int32]int64) map[int32]struct{} {
+	keys := make(map[int32]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
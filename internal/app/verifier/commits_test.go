@@ -0,0 +1,56 @@
+package verifier
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareCommits(t *testing.T) {
+	tests := []struct {
+		name                 string
+		committed, delivered map[int32]int64
+		want                 map[int32]PartitionCommit
+	}{
+		{
+			name:      "nothing",
+			committed: nil, delivered: nil,
+			want: map[int32]PartitionCommit{},
+		},
+		{
+			name:      "committed what was delivered",
+			committed: map[int32]int64{0: 10, 1: 20}, delivered: map[int32]int64{0: 10, 1: 20},
+			want: map[int32]PartitionCommit{0: {10, 10, CommitOK}, 1: {20, 20, CommitOK}},
+		},
+		{
+			name:      "lagging",
+			committed: map[int32]int64{0: 8}, delivered: map[int32]int64{0: 10},
+			want: map[int32]PartitionCommit{0: {8, 10, CommitLagging}},
+		},
+		{
+			name:      "ahead",
+			committed: map[int32]int64{0: 12}, delivered: map[int32]int64{0: 10},
+			want: map[int32]PartitionCommit{0: {12, 10, CommitAhead}},
+		},
+		{
+			name:      "delivered but never committed",
+			committed: map[int32]int64{}, delivered: map[int32]int64{2: 5},
+			want: map[int32]PartitionCommit{2: {0, 5, CommitLagging}},
+		},
+		{
+			// e.g. committed by an earlier run, nothing delivered in this one
+			name:      "committed but not delivered",
+			committed: map[int32]int64{3: 7}, delivered: map[int32]int64{},
+			want: map[int32]PartitionCommit{3: {7, 0, CommitAhead}},
+		},
+		{
+			name:      "mixed",
+			committed: map[int32]int64{0: 10, 1: 4, 2: 9}, delivered: map[int32]int64{0: 10, 1: 5, 2: 8},
+			want: map[int32]PartitionCommit{0: {10, 10, CommitOK}, 1: {4, 5, CommitLagging}, 2: {9, 8, CommitAhead}},
+		},
+	}
+	for _, tt := range tests {
+		if got := compareCommits(tt.committed, tt.delivered); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
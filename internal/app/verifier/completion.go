@@ -0,0 +1,114 @@
+package verifier
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// waitForCompletion blocks until every sent record has been processed and the
+// group has committed up to the high watermark of every partition. It gives up
+// when no progress is made for IdleTimeout, or when the overall deadline,
+// scaled to the number of generated records, is exceeded.
+func (v *Verifier) waitForCompletion() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	v.logger.Info("waiting records to be processed")
+
+	deadline := time.Now().Add(v.completionDeadline())
+	lastProgress := time.Now()
+	lastProcessed := v.totalProcessed()
+
+	for now := range ticker.C {
+		if v.allMessagesProcessed() && v.allOffsetsCommitted() {
+			v.logger.Info("all records has been stored")
+			return
+		}
+
+		if processed := v.totalProcessed(); processed != lastProcessed {
+			lastProcessed = processed
+			lastProgress = now
+		}
+
+		if now.Sub(lastProgress) >= v.cfg.IdleTimeout {
+			v.logger.Errorf("no records processed in the last %s, giving up", v.cfg.IdleTimeout)
+			return
+		}
+
+		if now.After(deadline) {
+			v.logger.Errorf("completion deadline of %s exceeded, giving up", v.completionDeadline())
+			return
+		}
+	}
+}
+
+// waitForIdle blocks until no record is processed for IdleTimeout or the
+// context is done.
+func (v *Verifier) waitForIdle(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	lastProgress := time.Now()
+	lastProcessed := v.totalProcessed()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if processed := v.totalProcessed(); processed != lastProcessed {
+				lastProcessed = processed
+				lastProgress = now
+			}
+
+			if now.Sub(lastProgress) >= v.cfg.IdleTimeout {
+				v.logger.Infof("no records processed in the last %s", v.cfg.IdleTimeout)
+				return
+			}
+		}
+	}
+}
+
+func (v *Verifier) completionDeadline() time.Duration {
+	generated := time.Duration(atomic.LoadInt64(&v.counts.totalGenerated))
+	return v.cfg.DeadlineBase + generated*v.cfg.DeadlinePerRecord
+}
+
+func (v *Verifier) totalProcessed() int64 {
+	return atomic.LoadInt64(&v.counts.totalFailed) + atomic.LoadInt64(&v.counts.totalInProgress) + atomic.LoadInt64(&v.counts.totalSuccess)
+}
+
+// allMessagesProcessed reports whether as many records were processed as
+// generated. Duplicates and records of failed batches that were written anyway
+// are processed too, so there may be more.
+func (v *Verifier) allMessagesProcessed() bool {
+	return v.totalProcessed() >= atomic.LoadInt64(&v.counts.totalGenerated)
+}
+
+// allOffsetsCommitted reports whether the group's committed offset has reached
+// the high watermark on every partition of the topic.
+func (v *Verifier) allOffsetsCommitted() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ends, err := v.admin.EndOffsets(ctx)
+	if err != nil {
+		v.logger.Errorf("listing end offsets: %v", err)
+		return false
+	}
+
+	committed, err := v.admin.CommittedOffsets(ctx)
+	if err != nil {
+		v.logger.Errorf("fetching committed offsets: %v", err)
+		return false
+	}
+
+	for p, end := range ends {
+		if committed[p] < end {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,109 @@
+package verifier
+
+import (
+	"sync"
+
+	"kafka-producer-consumer-tester/internal/pkg/producer"
+)
+
+// Distribution tells how the produced records were spread across the
+// partitions of the topic.
+type Distribution struct {
+	Partitioner string          `json:"partitioner"`
+	Partitions  map[int32]int64 `json:"partitions"`
+	Empty       int             `json:"empty"` // partitions no record was written to
+	Mean        float64         `json:"mean"`
+	Skew        float64         `json:"skew"` // records of the fullest partition over the mean
+
+	// Keyed records written to another partition than the Java client's
+	// default partitioner picks for their key, only counted when the number of
+	// partitions is known and the partitioner hashes keys.
+	Keyed          int64  `json:"keyed"`
+	JavaMismatches *int64 `json:"java_mismatches,omitempty"`
+}
+
+type distribution struct {
+	hashing bool // keyed records are compared with the Java client's pick
+
+	mu         sync.Mutex
+	partitions int // of the topic, 0 when unknown
+	counts     map[int32]int64
+	keyed      int64
+	mismatches int64
+}
+
+func newDistribution(partitioner string) *distribution {
+	return &distribution{hashing: producer.HashesKeys(partitioner), counts: map[int32]int64{}}
+}
+
+func (d *distribution) setPartitions(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.partitions = n
+}
+
+func (d *distribution) record(key []byte, partition int32) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.counts[partition]++
+
+	if key != nil {
+		d.keyed++
+		if d.hashing && d.partitions > 0 && producer.JavaPartition(key, d.partitions) != partition {
+			d.mismatches++
+		}
+	}
+}
+
+// snapshot returns a copy of the records written to every partition so far.
+func (d *distribution) snapshot() map[int32]int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	counts := make(map[int32]int64, d.partitions)
+	for p := int32(0); p < int32(d.partitions); p++ {
+		counts[p] = 0
+	}
+	for p, n := range d.counts {
+		counts[p] = n
+	}
+
+	return counts
+}
+
+func (v *Verifier) distributionStats() *Distribution {
+	counts := v.distribution.snapshot()
+	if len(counts) == 0 {
+		return nil
+	}
+
+	d := &Distribution{Partitioner: v.cfg.Partitioner, Partitions: counts}
+
+	var total, max int64
+	for _, n := range counts {
+		total += n
+		if n > max {
+			max = n
+		}
+		if n == 0 {
+			d.Empty++
+		}
+	}
+
+	d.Mean = float64(total) / float64(len(counts))
+	if d.Mean > 0 {
+		d.Skew = float64(max) / d.Mean
+	}
+
+	v.distribution.mu.Lock()
+	d.Keyed = v.distribution.keyed
+	if v.distribution.hashing && v.distribution.partitions > 0 {
+		mismatches := v.distribution.mismatches
+		d.JavaMismatches = &mismatches
+	}
+	v.distribution.mu.Unlock()
+
+	return d
+}
@@ -0,0 +1,100 @@
+package verifier
+
+import (
+	"reflect"
+	"testing"
+
+	"kafka-producer-consumer-tester/internal/pkg/producer"
+)
+
+func TestDistributionStats(t *testing.T) {
+	type write struct {
+		key       string // no key when empty
+		partition int32
+	}
+	writes := func(partition int32, n int) []write {
+		var w []write
+		for range n {
+			w = append(w, write{partition: partition})
+		}
+		return w
+	}
+	mismatches := func(n int64) *int64 { return &n }
+
+	// the Java client writes foobar to partition 0 out of 3
+	tests := []struct {
+		name        string
+		partitioner string
+		partitions  int
+		writes      []write
+		want        *Distribution
+	}{
+		{name: "nothing written to an unknown topic", partitioner: producer.Default},
+		{
+			name:        "even",
+			partitioner: producer.RoundRobin,
+			partitions:  2,
+			writes:      append(writes(0, 5), writes(1, 5)...),
+			want:        &Distribution{Partitioner: producer.RoundRobin, Partitions: map[int32]int64{0: 5, 1: 5}, Mean: 5, Skew: 1},
+		},
+		{
+			name:        "skewed with empty partitions",
+			partitioner: producer.Sticky,
+			partitions:  4,
+			writes:      append(writes(0, 6), writes(2, 2)...),
+			want:        &Distribution{Partitioner: producer.Sticky, Partitions: map[int32]int64{0: 6, 1: 0, 2: 2, 3: 0}, Empty: 2, Mean: 2, Skew: 3},
+		},
+		{
+			name:        "nothing written",
+			partitioner: producer.Sticky,
+			partitions:  2,
+			want:        &Distribution{Partitioner: producer.Sticky, Partitions: map[int32]int64{0: 0, 1: 0}, Empty: 2},
+		},
+		{
+			name:        "keys hashed like the Java client",
+			partitioner: producer.KeyHash,
+			partitions:  3,
+			writes:      []write{{"foobar", 0}, {"foobar", 0}, {partition: 1}},
+			want:        &Distribution{Partitioner: producer.KeyHash, Partitions: map[int32]int64{0: 2, 1: 1, 2: 0}, Empty: 1, Mean: 1, Skew: 2, Keyed: 2, JavaMismatches: mismatches(0)},
+		},
+		{
+			name:        "keys hashed otherwise",
+			partitioner: producer.Default,
+			partitions:  3,
+			writes:      []write{{"foobar", 0}, {"foobar", 1}, {"foobar", 2}},
+			want:        &Distribution{Partitioner: producer.Default, Partitions: map[int32]int64{0: 1, 1: 1, 2: 1}, Mean: 1, Skew: 1, Keyed: 3, JavaMismatches: mismatches(2)},
+		},
+		{
+			name:        "keys not hashed",
+			partitioner: producer.RoundRobin,
+			partitions:  3,
+			writes:      []write{{"foobar", 1}},
+			want:        &Distribution{Partitioner: producer.RoundRobin, Partitions: map[int32]int64{0: 0, 1: 1, 2: 0}, Empty: 2, Mean: 1.0 / 3, Skew: 3, Keyed: 1},
+		},
+		{
+			name:        "partitions unknown",
+			partitioner: producer.KeyHash,
+			writes:      []write{{"foobar", 1}, {partition: 0}},
+			want:        &Distribution{Partitioner: producer.KeyHash, Partitions: map[int32]int64{0: 1, 1: 1}, Mean: 1, Skew: 1, Keyed: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newTestVerifier(VerifierConfig{Partitioner: tt.partitioner})
+			v.distribution = newDistribution(tt.partitioner)
+			v.distribution.setPartitions(tt.partitions)
+
+			for _, w := range tt.writes {
+				var key []byte
+				if w.key != "" {
+					key = []byte(w.key)
+				}
+				v.distribution.record(key, w.partition)
+			}
+
+			if got := v.distributionStats(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
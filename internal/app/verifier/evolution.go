@@ -0,0 +1,96 @@
+package verifier
+
+import (
+	"sort"
+	"sync"
+
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+)
+
+// Compatibility tells how values written with a schema version were read
+// with a reader version. A read fails when decoding fails or when a field
+// both versions share is read as another value than written.
+type Compatibility struct {
+	Writer     string `json:"writer"`
+	Reader     string `json:"reader"`
+	Read       int64  `json:"read"`
+	Failed     int64  `json:"failed"`
+	Compatible bool   `json:"compatible"`
+	Error      string `json:"error,omitempty"` // of the first failure
+}
+
+// EvolutionStats is the compatibility matrix of the schema versions written
+// and read during the run.
+type EvolutionStats struct {
+	Matrix []Compatibility `json:"matrix"`
+}
+
+type evolution struct {
+	codec codec.Evolving
+
+	mu     sync.Mutex
+	matrix map[[2]string]*Compatibility
+}
+
+func newEvolution(c codec.Codec) *evolution {
+	ev, ok := c.(codec.Evolving)
+	if !ok {
+		return nil
+	}
+
+	return &evolution{codec: ev, matrix: map[[2]string]*Compatibility{}}
+}
+
+// check reads the value with every reader version.
+func (e *evolution) check(b []byte) {
+	if e == nil {
+		return
+	}
+
+	for _, reader := range e.codec.Readers() {
+		writer, err := e.codec.Check(reader, b)
+		if writer == "" {
+			return // not an evolution value at all, already reported by decode
+		}
+
+		e.mu.Lock()
+		c, ok := e.matrix[[2]string{writer, reader}]
+		if !ok {
+			c = &Compatibility{Writer: writer, Reader: reader}
+			e.matrix[[2]string{writer, reader}] = c
+		}
+		c.Read++
+		if err != nil {
+			c.Failed++
+			if c.Error == "" {
+				c.Error = err.Error()
+			}
+		}
+		e.mu.Unlock()
+	}
+}
+
+func (e *evolution) stats() *EvolutionStats {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s := &EvolutionStats{Matrix: make([]Compatibility, 0, len(e.matrix))}
+	for _, c := range e.matrix {
+		cc := *c
+		cc.Compatible = cc.Failed == 0
+		s.Matrix = append(s.Matrix, cc)
+	}
+
+	sort.Slice(s.Matrix, func(i, j int) bool {
+		if s.Matrix[i].Writer != s.Matrix[j].Writer {
+			return s.Matrix[i].Writer < s.Matrix[j].Writer
+		}
+		return s.Matrix[i].Reader < s.Matrix[j].Reader
+	})
+
+	return s
+}
@@ -0,0 +1,191 @@
+package verifier
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+	"kafka-producer-consumer-tester/internal/pkg/producer"
+)
+
+// Header modes, every one adding its own headers to the produced records.
+const (
+	HeaderStatic = "static" // the same header on every record
+	HeaderRandom = "random" // random binary bytes
+	HeaderUnique = "unique" // the event ID, unique per record
+	HeaderLarge  = "large"  // a single header of HeaderSize random bytes
+	HeaderMany   = "many"   // HeaderCount small headers
+)
+
+// headerPrefix tells the generated headers apart from the ones added by
+// others, e.g. the traceparent header of the tracing hooks.
+const headerPrefix = "tester-"
+
+// HeaderStats tell whether the headers of the consumed records are the ones
+// that were produced, byte for byte and in order.
+type HeaderStats struct {
+	Modes      []string `json:"modes"`
+	Checked    int64    `json:"checked"`
+	Missing    int64    `json:"missing"` // records without any of the generated headers
+	Mismatched int64    `json:"mismatched"`
+
+	MismatchedIDs []string `json:"mismatched_ids,omitempty"`
+	FirstMismatch string   `json:"first_mismatch,omitempty"`
+}
+
+// headers generates the headers of a record out of its event ID, so that the
+// consumer can regenerate and compare them without knowing what was sent.
+type headers struct {
+	modes []string
+	size  int
+	count int
+
+	checked, missing, mismatched int64
+
+	mu            sync.Mutex
+	mismatchedIDs []string
+	firstMismatch string
+}
+
+func newHeaders(modes []string, size, count int) (*headers, error) {
+	if len(modes) == 0 {
+		return nil, nil
+	}
+
+	for _, m := range modes {
+		switch m {
+		case HeaderStatic, HeaderRandom, HeaderUnique:
+		case HeaderLarge:
+			if size < 1 {
+				return nil, fmt.Errorf("large header size must be positive, got %d", size)
+			}
+		case HeaderMany:
+			if count < 1 {
+				return nil, fmt.Errorf("header count must be positive, got %d", count)
+			}
+		default:
+			return nil, fmt.Errorf("unknown header mode %q", m)
+		}
+	}
+
+	return &headers{modes: modes, size: size, count: count}, nil
+}
+
+func (h *headers) generate(id string) []producer.Header {
+	if h == nil {
+		return nil
+	}
+
+	var hs []producer.Header
+	for _, m := range h.modes {
+		switch m {
+		case HeaderStatic:
+			hs = append(hs, producer.Header{Key: headerPrefix + m, Value: []byte("kafka-producer-consumer-tester")})
+		case HeaderRandom:
+			hs = append(hs, producer.Header{Key: headerPrefix + m, Value: randomBytes(id, m, 32)})
+		case HeaderUnique:
+			hs = append(hs, producer.Header{Key: headerPrefix + m, Value: []byte(id)})
+		case HeaderLarge:
+			hs = append(hs, producer.Header{Key: headerPrefix + m, Value: randomBytes(id, m, h.size)})
+		case HeaderMany:
+			for i := 0; i < h.count; i++ {
+				hs = append(hs, producer.Header{Key: fmt.Sprintf("%s%s-%d", headerPrefix, m, i), Value: []byte(fmt.Sprintf("%s-%d", id, i))})
+			}
+		}
+	}
+
+	return hs
+}
+
+// randomBytes returns n pseudo-random bytes seeded by the event ID and the
+// header mode, using splitmix64.
+func randomBytes(id, mode string, n int) []byte {
+	f := fnv.New64a()
+	f.Write([]byte(mode))
+	f.Write([]byte(id))
+	seed := f.Sum64()
+
+	b := make([]byte, n+7)
+	for i := 0; i < n; i += 8 {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		binary.LittleEndian.PutUint64(b[i:], z^(z>>31))
+	}
+
+	return b[:n]
+}
+
+// check compares the generated headers of a consumed record with the ones
+// expected for its event ID.
+func (h *headers) check(id string, got []consumer.Header) {
+	if h == nil {
+		return
+	}
+
+	atomic.AddInt64(&h.checked, 1)
+
+	var own []consumer.Header
+	for _, g := range got {
+		if strings.HasPrefix(g.Key, headerPrefix) {
+			own = append(own, g)
+		}
+	}
+	if len(own) == 0 {
+		atomic.AddInt64(&h.missing, 1)
+		return
+	}
+
+	if reason := compareHeaders(h.generate(id), own); reason != "" {
+		atomic.AddInt64(&h.mismatched, 1)
+
+		h.mu.Lock()
+		h.mismatchedIDs = appendID(h.mismatchedIDs, id)
+		if h.firstMismatch == "" {
+			h.firstMismatch = fmt.Sprintf("%s: %s", id, reason)
+		}
+		h.mu.Unlock()
+	}
+}
+
+func compareHeaders(want []producer.Header, got []consumer.Header) string {
+	if len(want) != len(got) {
+		return fmt.Sprintf("%d headers instead of %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if want[i].Key != got[i].Key {
+			return fmt.Sprintf("header %d is %q instead of %q", i, got[i].Key, want[i].Key)
+		}
+		if !bytes.Equal(want[i].Value, got[i].Value) {
+			return fmt.Sprintf("header %q has a %d bytes value differing from the %d bytes produced", want[i].Key, len(got[i].Value), len(want[i].Value))
+		}
+	}
+
+	return ""
+}
+
+func (h *headers) stats() *HeaderStats {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return &HeaderStats{
+		Modes:      h.modes,
+		Checked:    atomic.LoadInt64(&h.checked),
+		Missing:    atomic.LoadInt64(&h.missing),
+		Mismatched: atomic.LoadInt64(&h.mismatched),
+
+		MismatchedIDs: h.mismatchedIDs,
+		FirstMismatch: h.firstMismatch,
+	}
+}
@@ -0,0 +1,179 @@
+package verifier
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+)
+
+func TestNewHeaders(t *testing.T) {
+	tests := []struct {
+		modes       []string
+		size, count int
+		ok          bool
+	}{
+		{nil, 0, 0, true},
+		{[]string{HeaderStatic, HeaderRandom, HeaderUnique}, 0, 0, true},
+		{[]string{HeaderLarge}, 1024, 0, true},
+		{[]string{HeaderLarge}, 0, 0, false},
+		{[]string{HeaderMany}, 0, 10, true},
+		{[]string{HeaderMany}, 0, 0, false},
+		{[]string{"huge"}, 0, 0, false},
+	}
+	for _, tt := range tests {
+		if _, err := newHeaders(tt.modes, tt.size, tt.count); (err == nil) != tt.ok {
+			t.Errorf("modes %q, size %d, count %d: got error %v", tt.modes, tt.size, tt.count, err)
+		}
+	}
+}
+
+func TestHeadersGenerate(t *testing.T) {
+	h, err := newHeaders([]string{HeaderMany, HeaderStatic, HeaderRandom, HeaderUnique, HeaderLarge}, 100, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := h.generate("a")
+
+	var keys []string
+	for _, g := range got {
+		keys = append(keys, g.Key)
+	}
+	// in the order of the modes
+	want := []string{"tester-many-0", "tester-many-1", "tester-many-2", "tester-static", "tester-random", "tester-unique", "tester-large"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got headers %q, want %q", keys, want)
+	}
+	if string(got[1].Value) != "a-1" || string(got[5].Value) != "a" || len(got[4].Value) != 32 || len(got[6].Value) != 100 {
+		t.Errorf("got values %q", got)
+	}
+
+	if !reflect.DeepEqual(h.generate("a"), got) {
+		t.Error("the headers of an event changed from one generation to the other")
+	}
+
+	other := h.generate("b")
+	if !bytes.Equal(other[3].Value, got[3].Value) {
+		t.Error("the static header differs between events")
+	}
+	if bytes.Equal(other[4].Value, got[4].Value) || bytes.Equal(other[6].Value, got[6].Value) {
+		t.Error("the random headers of two events are the same")
+	}
+
+	var none *headers
+	if hs := none.generate("a"); hs != nil {
+		t.Errorf("got headers %q without header mode", hs)
+	}
+}
+
+func TestHeadersCheck(t *testing.T) {
+	h, err := newHeaders([]string{HeaderStatic, HeaderRandom, HeaderUnique}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traceparent := consumer.Header{Key: "traceparent", Value: []byte("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")}
+
+	// received returns the headers generated for the event, changed
+	received := func(change func([]consumer.Header) []consumer.Header) []consumer.Header {
+		var hs []consumer.Header
+		for _, g := range h.generate("a") {
+			hs = append(hs, consumer.Header{Key: g.Key, Value: append([]byte{}, g.Value...)})
+		}
+		return change(hs)
+	}
+
+	tests := []struct {
+		name     string
+		got      []consumer.Header
+		missing  bool
+		mismatch string // part of the reason, when mismatched
+	}{
+		{name: "same", got: received(func(hs []consumer.Header) []consumer.Header { return hs })},
+		{
+			name: "traceparent added",
+			got: received(func(hs []consumer.Header) []consumer.Header {
+				return append([]consumer.Header{hs[0], traceparent}, hs[1:]...)
+			}),
+		},
+		{name: "none", got: nil, missing: true},
+		{name: "traceparent only", got: []consumer.Header{traceparent}, missing: true},
+		{
+			name: "one dropped",
+			got: received(func(hs []consumer.Header) []consumer.Header {
+				return append(hs[:1], hs[2:]...)
+			}),
+			mismatch: "2 headers instead of 3",
+		},
+		{
+			name: "one added",
+			got: received(func(hs []consumer.Header) []consumer.Header {
+				return append(hs, consumer.Header{Key: "tester-extra"})
+			}),
+			mismatch: "4 headers instead of 3",
+		},
+		{
+			name: "reordered",
+			got: received(func(hs []consumer.Header) []consumer.Header {
+				hs[0], hs[1] = hs[1], hs[0]
+				return hs
+			}),
+			mismatch: `header 0 is "tester-random" instead of "tester-static"`,
+		},
+		{
+			name: "one byte flipped",
+			got: received(func(hs []consumer.Header) []consumer.Header {
+				hs[1].Value[31] ^= 1
+				return hs
+			}),
+			mismatch: `header "tester-random" has a 32 bytes value`,
+		},
+		{
+			name: "truncated",
+			got: received(func(hs []consumer.Header) []consumer.Header {
+				hs[2].Value = hs[2].Value[:0]
+				return hs
+			}),
+			mismatch: `header "tester-unique" has a 0 bytes value differing from the 1 bytes produced`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, _ := newHeaders(h.modes, 0, 0)
+			checker.check("a", tt.got)
+
+			s := checker.stats()
+			if s.Checked != 1 {
+				t.Errorf("got %d checked, want 1", s.Checked)
+			}
+			if missing := s.Missing == 1; missing != tt.missing {
+				t.Errorf("got %d missing, want missing: %t", s.Missing, tt.missing)
+			}
+			mismatched := tt.mismatch != ""
+			if (s.Mismatched == 1) != mismatched || (len(s.MismatchedIDs) == 1) != mismatched {
+				t.Errorf("got %d mismatched, %q, want mismatched: %t", s.Mismatched, s.MismatchedIDs, mismatched)
+			}
+			if !strings.Contains(s.FirstMismatch, tt.mismatch) {
+				t.Errorf("got first mismatch %q, want it to contain %q", s.FirstMismatch, tt.mismatch)
+			}
+		})
+	}
+}
+
+func TestHeadersFirstMismatch(t *testing.T) {
+	h, _ := newHeaders([]string{HeaderUnique}, 0, 0)
+
+	h.check("a", []consumer.Header{{Key: "tester-unique", Value: []byte("b")}})
+	h.check("b", []consumer.Header{{Key: "tester-unique", Value: []byte("a")}})
+
+	s := h.stats()
+	if s.Mismatched != 2 || !reflect.DeepEqual(s.MismatchedIDs, []string{"a", "b"}) {
+		t.Errorf("got %d mismatched, %q, want 2, a and b", s.Mismatched, s.MismatchedIDs)
+	}
+	if !strings.HasPrefix(s.FirstMismatch, "a: ") {
+		t.Errorf("got first mismatch %q, want the one of a", s.FirstMismatch)
+	}
+}
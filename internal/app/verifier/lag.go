@@ -0,0 +1,164 @@
+package verifier
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LagSample is the consumer group lag observed at a point in time.
+type LagSample struct {
+	Time       time.Time       `json:"time"`
+	Total      int64           `json:"total"`
+	Partitions map[int32]int64 `json:"partitions"`
+}
+
+// maxLagSamples bounds the lag series kept, however long the run is.
+const maxLagSamples = 1000
+
+// lagMonitor periodically compares the group's committed offsets with the
+// partitions' end offsets and keeps the resulting time series. Once the series
+// is full, every other sample is dropped and sampling slows down by half, so
+// that the series covers the whole run at a coarser resolution.
+type lagMonitor struct {
+	v        *Verifier
+	interval time.Duration
+
+	mu      sync.Mutex
+	samples []LagSample
+	max     int64 // highest total lag sampled, kept or not
+	every   int   // only one sample out of every is kept
+	skipped int   // samples not kept since the last kept one
+
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newLagMonitor(v *Verifier, interval time.Duration) *lagMonitor {
+	return &lagMonitor{
+		v:        v,
+		interval: interval,
+		every:    1,
+
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+func (m *lagMonitor) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.quit:
+			return
+		case now := <-ticker.C:
+			sample, err := m.sample(now)
+			if err != nil {
+				m.v.logger.Errorf("sampling consumer lag: %v", err)
+				continue
+			}
+
+			m.keep(sample)
+
+			m.v.logger.RecordLag(sample.Partitions, sample.Total)
+
+			// broker telemetry is refreshed at the same pace as the lag
+			m.v.logger.RecordBrokers(m.v.brokerStats())
+		}
+	}
+}
+
+func (m *lagMonitor) sample(now time.Time) (LagSample, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.interval)
+	defer cancel()
+
+	ends, err := m.v.admin.EndOffsets(ctx)
+	if err != nil {
+		return LagSample{}, err
+	}
+
+	committed, err := m.v.admin.CommittedOffsets(ctx)
+	if err != nil {
+		return LagSample{}, err
+	}
+
+	sample := LagSample{Time: now, Partitions: make(map[int32]int64, len(ends))}
+	for p, end := range ends {
+		lag := end - committed[p]
+		if lag < 0 {
+			lag = 0
+		}
+
+		sample.Partitions[p] = lag
+		sample.Total += lag
+	}
+
+	return sample, nil
+}
+
+// keep adds the sample to the series, downsampling it when it is full.
+func (m *lagMonitor) keep(sample LagSample) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.max = max(m.max, sample.Total)
+
+	if m.skipped++; m.skipped < m.every {
+		return
+	}
+	m.skipped = 0
+
+	if len(m.samples) == maxLagSamples {
+		// the higher of every pair is kept, so peaks survive the downsampling
+		kept := m.samples[:0]
+		for i := 0; i+1 < len(m.samples); i += 2 {
+			a, b := m.samples[i], m.samples[i+1]
+			if b.Total > a.Total {
+				a = b
+			}
+			kept = append(kept, a)
+		}
+		m.samples = kept
+		m.every *= 2
+	}
+
+	m.samples = append(m.samples, sample)
+}
+
+// maxLag returns the highest total lag sampled, including the samples dropped
+// from the series.
+func (m *lagMonitor) maxLag() int64 {
+	if m == nil {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.max
+}
+
+// stop stops sampling and waits for the monitor to return. It can be called
+// more than once, so that it is also deferred on error paths.
+func (m *lagMonitor) stop() {
+	m.stopOnce.Do(func() {
+		close(m.quit)
+		<-m.done
+	})
+}
+
+func (m *lagMonitor) series() []LagSample {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]LagSample{}, m.samples...)
+}
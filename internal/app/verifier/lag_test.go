@@ -0,0 +1,76 @@
+package verifier
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLagKeep(t *testing.T) {
+	// totals of the samples taken, with a peak at 501 and a dip at 600
+	total := func(i int) int64 {
+		switch i {
+		case 501:
+			return 10_000
+		case 600:
+			return 0
+		}
+		return int64(i)
+	}
+
+	tests := []struct {
+		name    string
+		samples int
+		kept    int
+		every   int
+		last    int64 // total of the last sample kept
+	}{
+		{"below the limit", 10, 10, 1, 9},
+		{"full", maxLagSamples, maxLagSamples, 1, maxLagSamples - 1},
+		{"halved", maxLagSamples + 1, maxLagSamples/2 + 1, 2, maxLagSamples},
+		{"one of every two kept", maxLagSamples + 3, maxLagSamples/2 + 2, 2, maxLagSamples + 2},
+		{"one of every two skipped", maxLagSamples + 4, maxLagSamples/2 + 2, 2, maxLagSamples + 2},
+		{"halved twice", 3 * maxLagSamples, maxLagSamples/2 + 250, 4, 3*maxLagSamples - 4}, // one of every four from 2000 on
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newLagMonitor(newTestVerifier(VerifierConfig{}), time.Second)
+			for i := range tt.samples {
+				m.keep(LagSample{Total: total(i)})
+			}
+
+			s := m.series()
+			if len(s) != tt.kept || m.every != tt.every {
+				t.Fatalf("kept %d samples, one of every %d, want %d and %d", len(s), m.every, tt.kept, tt.every)
+			}
+			if last := s[len(s)-1].Total; last != tt.last {
+				t.Errorf("last sample kept %d, want %d", last, tt.last)
+			}
+			if m.maxLag() != 10_000 && tt.samples > 501 {
+				t.Errorf("max lag %d, want the peak", m.maxLag())
+			}
+		})
+	}
+}
+
+func TestLagKeepsTheHigherOfEveryPair(t *testing.T) {
+	m := newLagMonitor(newTestVerifier(VerifierConfig{}), time.Second)
+	for i := range maxLagSamples + 1 {
+		// pairs of a low and a high sample, then of a high and a low one
+		total := int64(i % 2)
+		if i >= maxLagSamples/2 {
+			total = int64(1 - i%2)
+		}
+		m.keep(LagSample{Total: total * 100})
+	}
+
+	for i, s := range m.series()[:maxLagSamples/2] {
+		if s.Total != 100 {
+			t.Fatalf("sample %d kept the lower of its pair", i)
+		}
+	}
+
+	var nilMonitor *lagMonitor
+	if nilMonitor.maxLag() != 0 || nilMonitor.series() != nil {
+		t.Error("a nil monitor reports lag")
+	}
+}
@@ -0,0 +1,117 @@
+package verifier
+
+import (
+	"kafka-producer-consumer-tester/internal/pkg/manifest"
+)
+
+// maxListedIDs caps the IDs listed per category in a reconciliation.
+const maxListedIDs = 100
+
+// Reconciliation compares the manifest of the events sent with the one of the
+// events seen, without connecting to Kafka.
+type Reconciliation struct {
+	Sent int64 `json:"sent"`
+	Seen int64 `json:"seen"`
+
+	Delivered  int64 `json:"delivered"`  // sent events seen at least once
+	Missing    int64 `json:"missing"`    // sent events never seen
+	Duplicates int64 `json:"duplicates"` // extra deliveries of sent events
+	Unexpected int64 `json:"unexpected"` // seen events that were never sent
+
+	StateMismatches int64 `json:"state_mismatches"`
+	Altered         int64 `json:"altered"` // seen with another payload than sent
+	Moved           int64 `json:"moved"`   // seen at another partition or offset than acknowledged
+
+	MissingIDs    []string `json:"missing_ids,omitempty"`
+	UnexpectedIDs []string `json:"unexpected_ids,omitempty"`
+}
+
+// Reconcile reads both manifests and reports what was lost, duplicated or
+// altered between producing and consuming.
+func (v *Verifier) Reconcile() error {
+	v.logger.Infof("reconciling %s against %s", v.cfg.SeenPath, v.cfg.ManifestPath)
+
+	r, err := reconcile(v.cfg.ManifestPath, v.cfg.SeenPath)
+	if err != nil {
+		v.logger.Errorf("reconciling manifests: %v", err)
+		return err
+	}
+
+	v.logger.Infof("%d events sent, %d seen: %d delivered, %d duplicates", r.Sent, r.Seen, r.Delivered, r.Duplicates)
+	if r.Missing > 0 {
+		v.logger.Errorf("%d events sent were never seen", r.Missing)
+	}
+	if r.Unexpected > 0 {
+		v.logger.Errorf("%d events seen were never sent", r.Unexpected)
+	}
+	if r.StateMismatches > 0 {
+		v.logger.Errorf("%d events seen with another state than sent", r.StateMismatches)
+	}
+	if r.Altered > 0 {
+		v.logger.Errorf("%d events seen with another payload than sent", r.Altered)
+	}
+	if r.Moved > 0 {
+		v.logger.Infof("%d events seen at another partition or offset than acknowledged", r.Moved)
+	}
+
+	return v.writeJSON(r)
+}
+
+func reconcile(sentPath, seenPath string) (*Reconciliation, error) {
+	r := &Reconciliation{}
+
+	sent := map[string]manifest.Entry{}
+	err := manifest.Read(sentPath, func(e manifest.Entry) error {
+		r.Sent++
+		sent[e.ID] = e
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]int, len(sent))
+	err = manifest.Read(seenPath, func(e manifest.Entry) error {
+		r.Seen++
+
+		s, ok := sent[e.ID]
+		if !ok {
+			r.Unexpected++
+			r.UnexpectedIDs = appendID(r.UnexpectedIDs, e.ID)
+			return nil
+		}
+
+		seen[e.ID]++
+		if seen[e.ID] > 1 {
+			r.Duplicates++
+			return nil
+		}
+
+		r.Delivered++
+		if e.State != s.State {
+			r.StateMismatches++
+		}
+		if e.Digest != s.Digest {
+			r.Altered++
+		}
+		if e.Partition != s.Partition || e.Offset != s.Offset {
+			r.Moved++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for id := range sent {
+		if seen[id] > 0 {
+			continue
+		}
+
+		r.Missing++
+		r.MissingIDs = appendID(r.MissingIDs, id)
+	}
+
+	return r, nil
+}
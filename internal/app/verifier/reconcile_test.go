@@ -0,0 +1,127 @@
+package verifier
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kafka-producer-consumer-tester/internal/pkg/manifest"
+)
+
+func writeManifest(t *testing.T, name, format string, entries []manifest.Entry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	w, err := manifest.Create(path, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if err := w.Write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestReconcile(t *testing.T) {
+	a := manifest.Entry{ID: "a", State: "success", Partition: 0, Offset: 0, Digest: 1}
+	b := manifest.Entry{ID: "b", State: "success", Partition: 1, Offset: 0, Digest: 2}
+	c := manifest.Entry{ID: "c", State: "failed", Partition: 2, Offset: 0, Digest: 3}
+
+	with := func(e manifest.Entry, change func(*manifest.Entry)) manifest.Entry {
+		change(&e)
+		return e
+	}
+
+	tests := []struct {
+		name       string
+		sent, seen []manifest.Entry
+		want       Reconciliation
+	}{
+		{
+			name: "all delivered",
+			sent: []manifest.Entry{a, b, c},
+			seen: []manifest.Entry{c, a, b},
+			want: Reconciliation{Sent: 3, Seen: 3, Delivered: 3},
+		},
+		{
+			name: "missing and duplicated",
+			sent: []manifest.Entry{a, b, c},
+			seen: []manifest.Entry{a, a, a, c},
+			want: Reconciliation{Sent: 3, Seen: 4, Delivered: 2, Missing: 1, Duplicates: 2, MissingIDs: []string{"b"}},
+		},
+		{
+			name: "unexpected",
+			sent: []manifest.Entry{a},
+			seen: []manifest.Entry{a, b, b},
+			want: Reconciliation{Sent: 1, Seen: 3, Delivered: 1, Unexpected: 2, UnexpectedIDs: []string{"b", "b"}},
+		},
+		{
+			name: "altered",
+			sent: []manifest.Entry{a, b, c},
+			seen: []manifest.Entry{
+				with(a, func(e *manifest.Entry) { e.State = "failed" }),
+				with(b, func(e *manifest.Entry) { e.Digest = 0 }),
+				with(c, func(e *manifest.Entry) { e.Offset = 7 }),
+			},
+			want: Reconciliation{Sent: 3, Seen: 3, Delivered: 3, StateMismatches: 1, Altered: 1, Moved: 1},
+		},
+		{
+			name: "only the first delivery is compared",
+			sent: []manifest.Entry{a},
+			seen: []manifest.Entry{a, with(a, func(e *manifest.Entry) { e.Partition = 5 })},
+			want: Reconciliation{Sent: 1, Seen: 2, Delivered: 1, Duplicates: 1},
+		},
+		{
+			name: "nothing seen",
+			sent: []manifest.Entry{a},
+			want: Reconciliation{Sent: 1, Missing: 1, MissingIDs: []string{"a"}},
+		},
+	}
+	for _, tt := range tests {
+		// the manifests of a produce and a consume run needn't share a format
+		for _, formats := range [][2]string{{manifest.NDJSON, manifest.NDJSON}, {manifest.Binary, manifest.NDJSON}, {manifest.NDJSON, manifest.Binary}} {
+			t.Run(fmt.Sprintf("%s/%s-%s", tt.name, formats[0], formats[1]), func(t *testing.T) {
+				r, err := reconcile(writeManifest(t, "sent", formats[0], tt.sent), writeManifest(t, "seen", formats[1], tt.seen))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(*r, tt.want) {
+					t.Errorf("got %+v, want %+v", *r, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestReconcileCapsListedIDs(t *testing.T) {
+	var sent []manifest.Entry
+	for i := range maxListedIDs * 2 {
+		sent = append(sent, manifest.Entry{ID: fmt.Sprint(i)})
+	}
+
+	r, err := reconcile(writeManifest(t, "sent", manifest.Binary, sent), writeManifest(t, "seen", manifest.Binary, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Missing != maxListedIDs*2 || len(r.MissingIDs) != maxListedIDs {
+		t.Errorf("got %d missing with %d IDs listed, want %d with %d", r.Missing, len(r.MissingIDs), maxListedIDs*2, maxListedIDs)
+	}
+}
+
+func TestReconcileMissingManifest(t *testing.T) {
+	sent := writeManifest(t, "sent", manifest.NDJSON, nil)
+
+	if _, err := reconcile(sent, filepath.Join(t.TempDir(), "seen")); err == nil {
+		t.Error("reconciling without seen manifest: got no error")
+	}
+	if _, err := reconcile(filepath.Join(t.TempDir(), "sent"), sent); err == nil {
+		t.Error("reconciling without sent manifest: got no error")
+	}
+}
@@ -0,0 +1,118 @@
+package verifier
+
+import (
+	"context"
+
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+	"kafka-producer-consumer-tester/internal/pkg/manifest"
+)
+
+// Tailer reads a topic without joining a consumer group.
+type Tailer interface {
+	Tail(context.Context, func(consumer.Record) error) error
+}
+
+// Replay is the outcome of re-reading a topic against the manifest of an
+// earlier run.
+type Replay struct {
+	Listed int64 `json:"listed"` // events in the manifest
+	Read   int64 `json:"read"`   // records read from the topic
+
+	Present    int64 `json:"present"`    // listed events found in the topic
+	Missing    int64 `json:"missing"`    // listed events not found
+	Duplicates int64 `json:"duplicates"` // extra copies of listed events
+	Other      int64 `json:"other"`      // records read that are not listed
+
+	Altered int64 `json:"altered"` // found with another payload than listed
+	Moved   int64 `json:"moved"`   // found at another partition or offset than listed
+
+	MissingIDs []string `json:"missing_ids,omitempty"`
+	AlteredIDs []string `json:"altered_ids,omitempty"`
+	MovedIDs   []string `json:"moved_ids,omitempty"`
+}
+
+// Replay re-reads the topic through t and checks every event of the manifest
+// is still there, with the same payload, partition and offset.
+func (v *Verifier) Replay(ctx context.Context, t Tailer) error {
+	v.logger.Infof("replaying %s", v.cfg.ManifestPath)
+
+	r := &Replay{}
+
+	listed := map[string]manifest.Entry{}
+	err := manifest.Read(v.cfg.ManifestPath, func(e manifest.Entry) error {
+		r.Listed++
+		listed[e.ID] = e
+		return nil
+	})
+	if err != nil {
+		v.logger.Errorf("reading manifest: %v", err)
+		return err
+	}
+
+	found := make(map[string]int, len(listed))
+	err = t.Tail(ctx, func(rec consumer.Record) error {
+		r.Read++
+
+		e, err := v.decode(rec.Value)
+		if err != nil {
+			r.Other++
+			return nil
+		}
+
+		l, ok := listed[e.ID]
+		if !ok {
+			r.Other++
+			return nil
+		}
+
+		found[e.ID]++
+		if found[e.ID] > 1 {
+			r.Duplicates++
+			return nil
+		}
+
+		r.Present++
+		if manifest.Digest(rec.Value) != l.Digest {
+			r.Altered++
+			r.AlteredIDs = appendID(r.AlteredIDs, e.ID)
+		}
+		if rec.Partition != l.Partition || rec.Offset != l.Offset {
+			r.Moved++
+			r.MovedIDs = appendID(r.MovedIDs, e.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		v.logger.Errorf("replaying topic: %v", err)
+		return err
+	}
+
+	for id := range listed {
+		if found[id] == 0 {
+			r.Missing++
+			r.MissingIDs = appendID(r.MissingIDs, id)
+		}
+	}
+
+	v.logger.Infof("%d events listed, %d records read: %d present, %d duplicates", r.Listed, r.Read, r.Present, r.Duplicates)
+	if r.Missing > 0 {
+		v.logger.Errorf("%d listed events are missing from the topic", r.Missing)
+	}
+	if r.Altered > 0 {
+		v.logger.Errorf("%d events found with another payload than listed", r.Altered)
+	}
+	if r.Moved > 0 {
+		v.logger.Errorf("%d events found at another partition or offset than listed", r.Moved)
+	}
+
+	return v.writeJSON(r)
+}
+
+// appendID lists id unless maxListedIDs are already listed.
+func appendID(ids []string, id string) []string {
+	if len(ids) >= maxListedIDs {
+		return ids
+	}
+	return append(ids, id)
+}
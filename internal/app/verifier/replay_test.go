@@ -0,0 +1,143 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+	"kafka-producer-consumer-tester/internal/pkg/manifest"
+)
+
+// records is a Tailer reading the records, then failing with err.
+type records struct {
+	recs []consumer.Record
+	err  error
+}
+
+func (r records) Tail(_ context.Context, fn func(consumer.Record) error) error {
+	for _, rec := range r.recs {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return r.err
+}
+
+func TestReplay(t *testing.T) {
+	c, _ := codec.New(codec.JSON)
+	encode := func(id, state string) []byte {
+		b, err := c.Encode(Event{ID: id, State: state})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// a and b are written to partition 0, c to partition 1
+	a := consumer.Record{Partition: 0, Offset: 0, Value: encode("a", Success)}
+	b := consumer.Record{Partition: 0, Offset: 1, Value: encode("b", Failed)}
+	cc := consumer.Record{Partition: 1, Offset: 0, Value: encode("c", InProgress)}
+
+	var listed []manifest.Entry
+	for _, rec := range []consumer.Record{a, b, cc} {
+		e, _ := c.Decode(rec.Value)
+		listed = append(listed, manifest.Entry{ID: e.ID, State: e.State, Partition: rec.Partition, Offset: rec.Offset, Digest: manifest.Digest(rec.Value)})
+	}
+
+	with := func(rec consumer.Record, change func(*consumer.Record)) consumer.Record {
+		change(&rec)
+		return rec
+	}
+
+	tests := []struct {
+		name string
+		read []consumer.Record
+		want Replay
+	}{
+		{
+			name: "all present",
+			read: []consumer.Record{a, b, cc},
+			want: Replay{Listed: 3, Read: 3, Present: 3},
+		},
+		{
+			name: "missing",
+			read: []consumer.Record{a, cc},
+			want: Replay{Listed: 3, Read: 2, Present: 2, Missing: 1, MissingIDs: []string{"b"}},
+		},
+		{
+			name: "duplicated",
+			read: []consumer.Record{a, b, with(b, func(r *consumer.Record) { r.Offset = 2 }), cc, with(cc, func(r *consumer.Record) { r.Offset = 1 })},
+			want: Replay{Listed: 3, Read: 5, Present: 3, Duplicates: 2},
+		},
+		{
+			name: "altered",
+			read: []consumer.Record{a, with(b, func(r *consumer.Record) { r.Value = encode("b", Success) }), cc},
+			want: Replay{Listed: 3, Read: 3, Present: 3, Altered: 1, AlteredIDs: []string{"b"}},
+		},
+		{
+			name: "moved",
+			read: []consumer.Record{
+				with(a, func(r *consumer.Record) { r.Partition = 1 }),
+				with(b, func(r *consumer.Record) { r.Offset = 5 }),
+				cc,
+			},
+			want: Replay{Listed: 3, Read: 3, Present: 3, Moved: 2, MovedIDs: []string{"a", "b"}},
+		},
+		{
+			name: "altered and moved",
+			read: []consumer.Record{a, b, with(cc, func(r *consumer.Record) { r.Offset, r.Value = 3, encode("c", Failed) })},
+			want: Replay{Listed: 3, Read: 3, Present: 3, Altered: 1, Moved: 1, AlteredIDs: []string{"c"}, MovedIDs: []string{"c"}},
+		},
+		{
+			name: "only the first copy is compared",
+			read: []consumer.Record{a, b, cc, with(a, func(r *consumer.Record) { r.Offset, r.Value = 9, encode("a", Failed) })},
+			want: Replay{Listed: 3, Read: 4, Present: 3, Duplicates: 1},
+		},
+		{
+			name: "other records",
+			read: []consumer.Record{a, b, cc, {Value: encode("d", Success)}, {Value: []byte("not an event")}},
+			want: Replay{Listed: 3, Read: 5, Present: 3, Other: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := filepath.Join(t.TempDir(), "replay.json")
+			v := newTestVerifier(VerifierConfig{ManifestPath: writeManifest(t, "manifest", manifest.Binary, listed), ReportPath: report})
+			v.codec = c
+
+			if err := v.Replay(context.Background(), records{recs: tt.read}); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(report)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Replay
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	v := newTestVerifier(VerifierConfig{ManifestPath: filepath.Join(t.TempDir(), "manifest")})
+	if err := v.Replay(context.Background(), records{}); err == nil {
+		t.Error("replaying without manifest: got no error")
+	}
+
+	v = newTestVerifier(VerifierConfig{ManifestPath: writeManifest(t, "manifest", manifest.NDJSON, nil)})
+	if err := v.Replay(context.Background(), records{err: errors.New("topic deleted")}); err == nil {
+		t.Error("replaying a topic failing to be read: got no error")
+	}
+}
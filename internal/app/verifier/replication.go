@@ -0,0 +1,78 @@
+package verifier
+
+import (
+	"time"
+
+	"kafka-producer-consumer-tester/internal/pkg/producer"
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+)
+
+// ReplicationStats describe how records produced to the source cluster made
+// it to the target cluster they are consumed from.
+type ReplicationStats struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+
+	Latency stats.Summary `json:"latency"` // from the source acknowledging a record to consuming it from the target
+
+	Missing    int64    `json:"missing"` // records sent that never arrived on the target
+	MissingIDs []string `json:"missing_ids,omitempty"`
+}
+
+func (v *Verifier) replicating() bool {
+	return v.cfg.Target != ""
+}
+
+// onAck returns what stores when the record carrying the event with the given
+// ID was acknowledged, nil unless replicating. It is stored as soon as the
+// record is, rather than with the whole batch, so that the record can't be
+// consumed from the target before.
+func (v *Verifier) onAck(id string) func(producer.Ack) {
+	if !v.replicating() {
+		return nil
+	}
+
+	return func(ack producer.Ack) {
+		if ack.Err == nil {
+			v.acked.Store(id, time.Now())
+		}
+	}
+}
+
+// recordReplication measures the replication latency of a record consumed
+// from the target. Duplicates and records of other runs are ignored.
+func (v *Verifier) recordReplication(id string) {
+	if !v.replicating() {
+		return
+	}
+
+	acked, ok := v.acked.LoadAndDelete(id)
+	if !ok {
+		return
+	}
+
+	v.replication.Observe(time.Since(acked.(time.Time)))
+}
+
+func (v *Verifier) replicationStats() *ReplicationStats {
+	if !v.replicating() {
+		return nil
+	}
+
+	s := &ReplicationStats{
+		Source:  v.cfg.Source,
+		Target:  v.cfg.Target,
+		Latency: v.replication.Summary(),
+	}
+
+	v.generatedRecords.Range(func(key, value any) bool {
+		id := key.(string)
+		if _, ok := v.stateMap(value.(string)).Load(id); !ok {
+			s.Missing++
+			s.MissingIDs = appendID(s.MissingIDs, id)
+		}
+		return true
+	})
+
+	return s
+}
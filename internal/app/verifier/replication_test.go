@@ -0,0 +1,39 @@
+package verifier
+
+import (
+	"errors"
+	"testing"
+
+	"kafka-producer-consumer-tester/internal/pkg/producer"
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+)
+
+func TestReplicationLatency(t *testing.T) {
+	v := newTestVerifier(VerifierConfig{Source: "source:9092", Target: "target:9092"})
+	v.replication = stats.NewHistogram()
+
+	// acknowledged and consumed before the batch returns and is stored
+	v.onAck("a")(producer.Ack{})
+	v.recordReplication("a")
+
+	// failed, then consumed anyway, e.g. written despite a timeout
+	v.onAck("b")(producer.Ack{Err: errors.New("timeout")})
+	v.recordReplication("b")
+
+	// consumed twice
+	v.onAck("c")(producer.Ack{})
+	v.recordReplication("c")
+	v.recordReplication("c")
+
+	if n := v.replication.Summary().Count; n != 2 {
+		t.Errorf("measured %d replication latencies, want 2", n)
+	}
+	v.acked.Range(func(id, _ any) bool {
+		t.Errorf("%s still waiting to be consumed", id)
+		return true
+	})
+
+	if onAck := newTestVerifier(VerifierConfig{}).onAck("a"); onAck != nil {
+		t.Error("acks stored without replication")
+	}
+}
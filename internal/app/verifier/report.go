@@ -0,0 +1,237 @@
+package verifier
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+	"kafka-producer-consumer-tester/internal/pkg/telemetry"
+)
+
+// Report summarizes a verification run. It is logged once the run completes
+// and, when a path is configured, written to disk as JSON.
+type Report struct {
+	Generated  int64 `json:"generated"`
+	Processed  int64 `json:"processed"`
+	Failed     int64 `json:"failed"`
+	InProgress int64 `json:"in_progress"`
+	Success    int64 `json:"success"`
+
+	Accounting *Accounting `json:"accounting,omitempty"`
+	LossPath   string      `json:"loss_path"`
+
+	CommitAudit *CommitAudit `json:"commit_audit,omitempty"`
+
+	Throughput *Throughput   `json:"throughput"`
+	Latency    stats.Summary `json:"latency"`
+	Codec      *CodecStats   `json:"codec"`
+
+	Evolution *EvolutionStats `json:"evolution,omitempty"`
+
+	Affinity *AffinityStats `json:"affinity,omitempty"`
+
+	Distribution *Distribution `json:"distribution,omitempty"`
+
+	Headers *HeaderStats `json:"headers,omitempty"`
+
+	Sizes *SizeStats `json:"sizes,omitempty"`
+
+	Checksums *ChecksumStats `json:"checksums,omitempty"`
+
+	Soak *SoakStats `json:"soak,omitempty"`
+
+	Replication *ReplicationStats `json:"replication,omitempty"`
+
+	Brokers []telemetry.BrokerStats `json:"brokers"`
+
+	Lag    []LagSample `json:"lag"`
+	MaxLag int64       `json:"max_lag"`
+
+	Errors        []string `json:"errors"`
+	ErrorsDropped int64    `json:"errors_dropped,omitempty"` // errors beyond the ones listed in soak mode
+}
+
+func (v *Verifier) buildReport() *Report {
+	var commitAudit *CommitAudit
+	if v.consumer != nil {
+		commitAudit = v.auditCommits()
+	}
+
+	v.errs.Lock()
+	errs, dropped := append([]string{}, v.errList...), v.droppedErrors
+	v.errs.Unlock()
+
+	r := &Report{
+		Generated:  atomic.LoadInt64(&v.counts.totalGenerated),
+		Processed:  v.totalProcessed(),
+		Failed:     atomic.LoadInt64(&v.counts.totalFailed),
+		InProgress: atomic.LoadInt64(&v.counts.totalInProgress),
+		Success:    atomic.LoadInt64(&v.counts.totalSuccess),
+
+		Accounting: v.accounting,
+
+		CommitAudit: commitAudit,
+
+		Throughput: v.throughput.summary(),
+		Latency:    v.latency.Summary(),
+		Codec:      v.codecSummary(),
+
+		Evolution: v.evolution.stats(),
+
+		Affinity: v.affinity.stats(),
+
+		Distribution: v.distributionStats(),
+
+		Headers: v.headers.stats(),
+
+		Sizes: v.sizes.stats(),
+
+		Checksums: v.checksums.stats(),
+
+		Replication: v.replicationStats(),
+
+		Brokers: v.brokerStats(),
+
+		Lag: v.lag.series(),
+
+		Errors:        errs,
+		ErrorsDropped: dropped,
+	}
+	r.LossPath = r.lossPath(v.consumer != nil)
+
+	if v.window != nil {
+		r.Soak = v.window.summary(time.Since(v.started))
+	}
+
+	r.MaxLag = v.lag.maxLag()
+
+	return r
+}
+
+func (v *Verifier) report() error {
+	r := v.buildReport()
+	v.logReport(r)
+	v.result = r
+
+	return v.writeJSON(r)
+}
+
+// Result returns the report of the completed run, nil until then.
+func (v *Verifier) Result() *Report {
+	return v.result
+}
+
+// interimReport is written periodically during a soak run. Only the window
+// stats are logged since the run totals are still moving.
+func (v *Verifier) interimReport() error {
+	r := v.buildReport()
+	v.logger.Infof("soak %s: %d verified, %d missing, %d duplicates, %d late, %d pending", r.Soak.Duration.Round(time.Second), r.Soak.Verified, r.Soak.Missing, r.Soak.Duplicates, r.Soak.Late, r.Soak.Pending)
+
+	return v.writeJSON(r)
+}
+
+func (v *Verifier) logReport(r *Report) {
+
+	v.logger.Infof("generated %d records, processed %d", r.Generated, r.Processed)
+	if r.Accounting != nil {
+		v.logger.Infof("broker appended %d records, producer acknowledged %d", r.Accounting.Appended, r.Accounting.Produced)
+		if r.Accounting.Missing > 0 {
+			v.logger.Errorf("broker-side accounting mismatch: %d acknowledged records not appended", r.Accounting.Missing)
+		}
+		if r.Accounting.Extra > 0 {
+			v.logger.Infof("broker appended %d records beyond the acknowledged ones, e.g. retried or from other producers", r.Accounting.Extra)
+		}
+	}
+	if r.LossPath != "none" {
+		v.logger.Errorf("loss path: %s", r.LossPath)
+	}
+	if r.CommitAudit != nil {
+		v.logger.Infof("%d commits made, %d failed", r.CommitAudit.Commits, len(r.CommitAudit.Failed))
+		for p, pc := range r.CommitAudit.Partitions {
+			if pc.Status != CommitOK {
+				v.logger.Errorf("partition %d commit %s: committed %d, delivered %d", p, pc.Status, pc.Committed, pc.Delivered)
+			}
+		}
+	}
+	v.logger.Infof("produced %.0f records/s, %.0f bytes/s after a %s warm-up", r.Throughput.RecordsPerSec, r.Throughput.BytesPerSec, r.Throughput.Warmup)
+	v.logger.Infof("end-to-end latency p50 %s, p99 %s, max %s", r.Latency.P50, r.Latency.P99, r.Latency.Max)
+	v.logger.Infof("%s codec: %.0f bytes per event, encoded in %s, decoded in %s", r.Codec.Name, r.Codec.MeanSize, r.Codec.EncodeTime, r.Codec.DecodeTime)
+	if r.Evolution != nil {
+		for _, c := range r.Evolution.Matrix {
+			if c.Compatible {
+				v.logger.Infof("schema %s read as %s: %d read, compatible", c.Writer, c.Reader, c.Read)
+			} else {
+				v.logger.Errorf("schema %s read as %s: %d of %d reads failed: %s", c.Writer, c.Reader, c.Failed, c.Read, c.Error)
+			}
+		}
+	}
+	if d := r.Distribution; d != nil {
+		v.logger.Infof("%s partitioner: %.0f records per partition on average, skew %.2f, %d partitions empty", d.Partitioner, d.Mean, d.Skew, d.Empty)
+		if d.JavaMismatches != nil && *d.JavaMismatches > 0 {
+			v.logger.Errorf("%d of %d keyed records not written to the partition the Java client would pick", *d.JavaMismatches, d.Keyed)
+		}
+	}
+	if r.Affinity != nil {
+		v.logger.Infof("%d %s keys: %d split on produce, %d split on consume, %d moved, %d split across partition consumers", r.Affinity.Keys, r.Affinity.Strategy, r.Affinity.ProducedSplit, r.Affinity.ConsumedSplit, r.Affinity.Moved, r.Affinity.WorkerSplit)
+		if r.Affinity.Untracked > 0 {
+			v.logger.Infof("%d records with keys beyond the %d tracked", r.Affinity.Untracked, r.Affinity.Keys)
+		}
+		if len(r.Affinity.Broken) > 0 {
+			v.logger.Errorf("keys without partition affinity: %s", strings.Join(r.Affinity.Broken, ", "))
+		}
+	}
+	if h := r.Headers; h != nil {
+		v.logger.Infof("%s headers checked on %d records", strings.Join(h.Modes, ", "), h.Checked)
+		if h.Missing > 0 {
+			v.logger.Errorf("headers missing on %d records", h.Missing)
+		}
+		if h.Mismatched > 0 {
+			v.logger.Errorf("headers altered on %d records, first %s", h.Mismatched, h.FirstMismatch)
+		}
+	}
+	if c := r.Checksums; c != nil {
+		v.logger.Infof("%s checksums verified on %d events", c.Algorithm, c.Verified)
+		if c.Corrupted > 0 || r.Codec.Failed > 0 {
+			v.logger.Errorf("%d events corrupted, %d values undecodable", c.Corrupted, r.Codec.Failed)
+		}
+	}
+	if r.Sizes != nil {
+		for _, b := range r.Sizes.Buckets {
+			if b.Failed > 0 {
+				v.logger.Errorf("payloads up to %s: %d of %d failed: %s", formatBytes(b.UpTo), b.Failed, b.Produced+b.Failed, formatErrors(b.Errors))
+			} else {
+				v.logger.Infof("payloads up to %s: %d produced, %d consumed", formatBytes(b.UpTo), b.Produced, b.Consumed)
+			}
+		}
+	}
+	for _, b := range r.Brokers {
+		v.logger.Infof("%s -> broker %s: response wait p99 %s, write p99 %s, throttled %s", b.Client, b.Broker, b.ResponseWait.P99, b.WriteLatency.P99, b.ThrottleTime)
+	}
+	if r.Replication != nil {
+		v.logger.Infof("replication %s -> %s latency p50 %s, p99 %s, max %s", r.Replication.Source, r.Replication.Target, r.Replication.Latency.P50, r.Replication.Latency.P99, r.Replication.Latency.Max)
+		if r.Replication.Missing > 0 {
+			v.logger.Errorf("%d records missing on %s", r.Replication.Missing, r.Replication.Target)
+		}
+	}
+	v.logger.Infof("max consumer lag observed: %d", r.MaxLag)
+	if r.Soak != nil {
+		v.logger.Infof("soak window: %d verified, %d missing, %d duplicates, %d late", r.Soak.Verified, r.Soak.Missing, r.Soak.Duplicates, r.Soak.Late)
+	}
+	v.logger.Infof("%d unexpected errors detected", int64(len(r.Errors))+r.ErrorsDropped)
+}
+
+func (v *Verifier) writeJSON(r any) error {
+	if v.cfg.ReportPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(v.cfg.ReportPath, data, 0o644)
+}
@@ -0,0 +1,122 @@
+package verifier
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SizeBucket counts the payloads of sizes up to its upper bound, and above
+// the bound of the previous bucket.
+type SizeBucket struct {
+	UpTo     int              `json:"up_to"` // bytes
+	Produced int64            `json:"produced"`
+	Failed   int64            `json:"failed"`
+	Consumed int64            `json:"consumed"`
+	Errors   map[string]int64 `json:"errors,omitempty"` // produce errors, e.g. MESSAGE_TOO_LARGE
+}
+
+// SizeStats tell which payload sizes made it through, by power of two buckets.
+type SizeStats struct {
+	Distribution string       `json:"distribution"`
+	Buckets      []SizeBucket `json:"buckets"`
+}
+
+type sizes struct {
+	distribution string
+
+	mu      sync.Mutex
+	buckets map[int]*SizeBucket
+}
+
+func newSizes(distribution string) *sizes {
+	return &sizes{distribution: distribution, buckets: map[int]*SizeBucket{}}
+}
+
+// bucket returns the bucket of the given size, its bound being the next power
+// of two.
+func (s *sizes) bucket(size int) *SizeBucket {
+	upTo := 1
+	if size > 1 {
+		upTo = 1 << bits.Len(uint(size-1))
+	}
+
+	b, ok := s.buckets[upTo]
+	if !ok {
+		b = &SizeBucket{UpTo: upTo}
+		s.buckets[upTo] = b
+	}
+	return b
+}
+
+func (s *sizes) produced(size int, err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.bucket(size)
+	if err == nil {
+		b.Produced++
+		return
+	}
+
+	b.Failed++
+	if b.Errors == nil {
+		b.Errors = map[string]int64{}
+	}
+	b.Errors[err.Error()]++
+}
+
+func (s *sizes) consumed(size int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bucket(size).Consumed++
+}
+
+func (s *sizes) stats() *SizeStats {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := &SizeStats{Distribution: s.distribution}
+	for _, b := range s.buckets {
+		stats.Buckets = append(stats.Buckets, *b)
+	}
+	sort.Slice(stats.Buckets, func(i, j int) bool { return stats.Buckets[i].UpTo < stats.Buckets[j].UpTo })
+
+	return stats
+}
+
+// formatBytes formats a power of two size, e.g. 4KiB.
+func formatBytes(n int) string {
+	for _, unit := range []string{"B", "KiB", "MiB"} {
+		if n < 1024 || n%1024 != 0 {
+			return fmt.Sprintf("%d%s", n, unit)
+		}
+		n /= 1024
+	}
+	return fmt.Sprintf("%dGiB", n)
+}
+
+func formatErrors(errs map[string]int64) string {
+	parts := make([]string, 0, len(errs))
+	for err, n := range errs {
+		parts = append(parts, fmt.Sprintf("%d x %s", n, err))
+	}
+	sort.Strings(parts)
+
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,189 @@
+package verifier
+
+import (
+	"sync"
+	"time"
+)
+
+// SoakStats is the outcome of the sliding window verification of a soak run.
+type SoakStats struct {
+	Duration   time.Duration `json:"duration"`
+	Window     time.Duration `json:"window"`
+	Verified   int64         `json:"verified"`
+	Missing    int64         `json:"missing"`
+	Duplicates int64         `json:"duplicates"`
+	Pending    int64         `json:"pending"`
+	Late       int64         `json:"late"` // records consumed once already verified and evicted, mostly duplicates
+}
+
+// Bounds of what a soak run keeps in memory besides its window, however long
+// it is; what goes beyond is only counted.
+const (
+	soakMaxErrors  = 1000
+	soakMaxKeys    = 100_000
+	soakMaxEvicted = 100_000
+)
+
+type bucket struct {
+	start time.Time
+	ids   []string
+}
+
+// window groups the IDs of sent records into time buckets. Once a bucket is
+// older than the window, its records are expected to have been processed: they
+// are verified and evicted from the generated and state maps, so memory stays
+// bounded however long the run is.
+type window struct {
+	v *Verifier
+
+	size  time.Duration
+	slice time.Duration
+
+	mu      sync.Mutex
+	buckets []*bucket
+	stats   SoakStats
+
+	// the IDs evicted last, in a ring, so that records consumed after being
+	// evicted aren't stored again
+	evicted map[string]struct{}
+	ring    []string
+	next    int
+}
+
+func newWindow(v *Verifier, size time.Duration) *window {
+	return &window{v: v, size: size, slice: max(size/10, 1), stats: SoakStats{Window: size}, evicted: map[string]struct{}{}} // sweeping ticker needs a positive slice
+}
+
+// late tells whether the record was consumed after being verified and evicted,
+// counting it if so. Records evicted before the last soakMaxEvicted ones are
+// told apart by being sent two windows ago.
+func (w *window) late(e Event) bool {
+	if w == nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, ok := w.evicted[e.ID]
+	if !ok && (e.SentAt == 0 || time.Since(time.Unix(0, e.SentAt)) < 2*w.size) {
+		return false
+	}
+
+	w.stats.Late++
+	return true
+}
+
+// remember adds the ID to the ring of evicted ones, forgetting the oldest once
+// it is full.
+func (w *window) remember(id string) {
+	if len(w.ring) < soakMaxEvicted {
+		w.ring = append(w.ring, id)
+	} else {
+		delete(w.evicted, w.ring[w.next])
+		w.ring[w.next] = id
+		w.next = (w.next + 1) % soakMaxEvicted
+	}
+	w.evicted[id] = struct{}{}
+}
+
+func (w *window) add(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if n := len(w.buckets); n == 0 || now.Sub(w.buckets[n-1].start) >= w.slice {
+		w.buckets = append(w.buckets, &bucket{start: now})
+	}
+
+	last := w.buckets[len(w.buckets)-1]
+	last.ids = append(last.ids, id)
+	w.stats.Pending++
+}
+
+// sweep verifies and evicts every bucket that started before the cutoff.
+func (w *window) sweep(cutoff time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	expired := 0
+	for _, b := range w.buckets {
+		if !b.start.Before(cutoff) {
+			break
+		}
+
+		for _, id := range b.ids {
+			w.verify(id)
+		}
+		expired++
+	}
+
+	w.buckets = w.buckets[expired:]
+}
+
+func (w *window) verify(id string) {
+	v := w.v
+	defer v.evict(id)
+	defer w.remember(id)
+
+	w.stats.Pending--
+
+	st, ok := v.generatedRecords.Load(id)
+	if !ok {
+		w.stats.Missing++
+		return
+	}
+
+	val, ok := v.stateMap(st.(string)).Load(id)
+	if !ok {
+		w.stats.Missing++
+		v.addUnexpectedError("record " + id + " not processed within the soak window")
+		return
+	}
+
+	w.stats.Verified++
+	if count := val.(*EventState).Count; count > 1 {
+		w.stats.Duplicates += int64(count - 1)
+	}
+}
+
+func (w *window) summary(duration time.Duration) *SoakStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s := w.stats
+	s.Duration = duration
+
+	return &s
+}
+
+func (v *Verifier) evict(id string) {
+	v.generatedRecords.Delete(id)
+	v.acked.Delete(id)
+	v.failedRecords.Delete(id)
+	v.inProgressRecords.Delete(id)
+	v.successRecords.Delete(id)
+}
+
+// soak sweeps the window as it slides and writes an interim report every
+// report interval, until quit is closed.
+func (v *Verifier) soak(quit <-chan struct{}) {
+	sweep := time.NewTicker(v.window.slice)
+	defer sweep.Stop()
+
+	report := time.NewTicker(v.cfg.SoakReportInterval)
+	defer report.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case now := <-sweep.C:
+			v.window.sweep(now.Add(-v.window.size))
+		case <-report.C:
+			if err := v.interimReport(); err != nil {
+				v.logger.Errorf("writing interim report: %v", err)
+			}
+		}
+	}
+}
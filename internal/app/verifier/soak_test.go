@@ -0,0 +1,104 @@
+package verifier
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWindowSweep(t *testing.T) {
+	v := newTestVerifier(VerifierConfig{})
+	v.window = newWindow(v, time.Minute)
+
+	sent := map[string]string{"a": Success, "b": Failed, "c": InProgress}
+	for id, st := range sent {
+		v.generatedRecords.Store(id, st)
+		v.window.add(id)
+	}
+	v.storeProcessedRecord("a", Success)
+	v.storeProcessedRecord("b", Failed)
+	v.storeProcessedRecord("b", Failed)
+
+	// nothing is verified before the window slid past the records
+	v.window.sweep(time.Now().Add(-time.Minute))
+	if s := v.window.summary(0); s.Pending != 3 || s.Verified != 0 {
+		t.Fatalf("swept records still in the window: %+v", s)
+	}
+
+	v.window.sweep(time.Now().Add(time.Second))
+
+	s := v.window.summary(time.Hour)
+	want := SoakStats{Duration: time.Hour, Window: time.Minute, Verified: 2, Missing: 1, Duplicates: 1}
+	if *s != want {
+		t.Errorf("got %+v, want %+v", *s, want)
+	}
+	for id, st := range sent {
+		if _, ok := v.generatedRecords.Load(id); ok {
+			t.Errorf("%s still generated after the sweep", id)
+		}
+		if _, ok := v.stateMap(st).Load(id); ok {
+			t.Errorf("%s still processed after the sweep", id)
+		}
+	}
+	if len(v.errList) != 1 {
+		t.Errorf("got errors %q, want one for the missing record", v.errList)
+	}
+}
+
+func TestWindowLate(t *testing.T) {
+	v := newTestVerifier(VerifierConfig{})
+	v.window = newWindow(v, time.Minute)
+
+	v.generatedRecords.Store("a", Success)
+	v.window.add("a")
+	v.storeProcessedRecord("a", Success)
+	v.window.sweep(time.Now().Add(time.Second))
+
+	now := time.Now()
+	tests := []struct {
+		e    Event
+		late bool
+	}{
+		{Event{ID: "a", SentAt: now.UnixNano()}, true}, // evicted
+		{Event{ID: "a"}, true},
+		{Event{ID: "b", SentAt: now.UnixNano()}, false},
+		{Event{ID: "b"}, false},
+		{Event{ID: "c", SentAt: now.Add(-90 * time.Second).UnixNano()}, false},
+		{Event{ID: "c", SentAt: now.Add(-3 * time.Minute).UnixNano()}, true}, // two windows ago
+	}
+	for _, tt := range tests {
+		if got := v.window.late(tt.e); got != tt.late {
+			t.Errorf("%+v: late %t, want %t", tt.e, got, tt.late)
+		}
+	}
+	if s := v.window.summary(0); s.Late != 3 {
+		t.Errorf("got %d late, want 3", s.Late)
+	}
+
+	var w *window
+	if w.late(Event{ID: "a"}) {
+		t.Error("late without soak window")
+	}
+}
+
+func TestWindowRemembersBoundedEvictions(t *testing.T) {
+	w := newWindow(newTestVerifier(VerifierConfig{}), time.Minute)
+
+	for i := range soakMaxEvicted + 10 {
+		w.remember(fmt.Sprint(i))
+	}
+
+	if len(w.evicted) != soakMaxEvicted || len(w.ring) != soakMaxEvicted {
+		t.Errorf("remembering %d IDs out of %d", len(w.evicted), soakMaxEvicted)
+	}
+	for _, id := range []string{"0", "9"} {
+		if _, ok := w.evicted[id]; ok {
+			t.Errorf("%s still remembered", id)
+		}
+	}
+	for _, id := range []string{"10", fmt.Sprint(soakMaxEvicted + 9)} {
+		if _, ok := w.evicted[id]; !ok {
+			t.Errorf("%s forgotten", id)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package verifier
+
+import (
+	"sync"
+	"time"
+)
+
+// Throughput is the produce rate achieved once the warm-up period is over.
+type Throughput struct {
+	Warmup        time.Duration `json:"warmup"`
+	Records       int64         `json:"records"`
+	Bytes         int64         `json:"bytes"`
+	Duration      time.Duration `json:"duration"`
+	RecordsPerSec float64       `json:"records_per_sec"`
+	BytesPerSec   float64       `json:"bytes_per_sec"`
+}
+
+type throughputStats struct {
+	mu sync.Mutex
+
+	warmup time.Duration
+	start  time.Time
+	end    time.Time
+
+	records int64
+	bytes   int64
+}
+
+// record accounts a batch acknowledged at the given time, elapsed being the
+// time since producing started. Batches sent during the warm-up are ignored.
+func (s *throughputStats) record(started time.Time, elapsed time.Duration, records, bytes int) {
+	if elapsed < s.warmup {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.start.IsZero() {
+		s.start = started
+	}
+	s.end = time.Now()
+	s.records += int64(records)
+	s.bytes += int64(bytes)
+}
+
+func (s *throughputStats) summary() *Throughput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &Throughput{Warmup: s.warmup, Records: s.records, Bytes: s.bytes, Duration: s.end.Sub(s.start)}
+	if secs := t.Duration.Seconds(); secs > 0 {
+		t.RecordsPerSec = float64(t.Records) / secs
+		t.BytesPerSec = float64(t.Bytes) / secs
+	}
+
+	return t
+}
@@ -2,7 +2,6 @@ package verifier
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"math/rand"
 	"sync"
@@ -10,6 +9,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+	"kafka-producer-consumer-tester/internal/pkg/consumer"
+	"kafka-producer-consumer-tester/internal/pkg/load"
+	"kafka-producer-consumer-tester/internal/pkg/manifest"
+	"kafka-producer-consumer-tester/internal/pkg/producer"
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+	"kafka-producer-consumer-tester/internal/pkg/telemetry"
 )
 
 const (
@@ -20,11 +27,21 @@ const (
 
 type Producer interface {
 	Produce(context.Context, []byte) error
-	ProduceBatch(ctx context.Context, payloads [][]byte) error
+	ProduceBatch(ctx context.Context, msgs []producer.Message) ([]producer.Ack, error)
+	ProduceTo(ctx context.Context, partition int32, payload []byte) error
+	BrokerStats() []telemetry.BrokerStats
 }
 
 type Consumer interface {
-	Consume(func(chan [][]byte)) error
+	Consume(func(chan []consumer.Record)) error
+	Delivered() map[int32]int64
+	Commits() (int, []consumer.CommitAudit)
+	BrokerStats() []telemetry.BrokerStats
+}
+
+type Admin interface {
+	EndOffsets(context.Context) (map[int32]int64, error)
+	CommittedOffsets(context.Context) (map[int32]int64, error)
 }
 
 type Logger interface {
@@ -34,6 +51,15 @@ type Logger interface {
 	Error(string)
 
 	Infof(string, ...any)
+	Errorf(string, ...any)
+
+	RecordLag(map[int32]int64, int64)
+	RecordDistribution(map[int32]int64)
+	RecordBrokers([]telemetry.BrokerStats)
+	RecordLatency(time.Duration)
+	RecordProduceError()
+	RecordProbeLost()
+	RecordAvailability(float64)
 
 	AddedProcessor()
 	RemovedProcessor()
@@ -44,12 +70,57 @@ type EventState struct {
 	Count int // times a single event was consumed
 }
 
-type Event struct {
-	ID    string
-	State string
+type Event = codec.Event
+
+type VerifierConfig struct {
+	Batches   int
+	BatchSize int
+
+	IdleTimeout       time.Duration
+	DeadlineBase      time.Duration
+	DeadlinePerRecord time.Duration
+
+	LagInterval time.Duration
+
+	Shape    load.ShapeConfig
+	Rate     float64 // peak records per second, unlimited when 0
+	ByteRate float64 // peak bytes per second, unlimited when 0
+	Warmup   time.Duration
+
+	SoakDuration       time.Duration // produce for this long instead of a fixed number of batches
+	SoakWindow         time.Duration // time a record has to be processed before it is verified and evicted
+	SoakReportInterval time.Duration
+
+	CanaryInterval       time.Duration
+	CanaryTimeout        time.Duration // a probe not consumed within this time is lost
+	CanaryReportInterval time.Duration
+
+	ManifestPath   string // events sent are listed here when set
+	SeenPath       string // events consumed are listed here when set
+	ManifestFormat string // ndjson or binary
+
+	Codec codec.Codec // encodes the events, JSON when nil
+	Size  load.SizeConfig
+
+	Checksum string // none, crc32c or sha256
+
+	KeyStrategy string // none, id, hot or random
+	HotKeys     int    // number of keys of the hot strategy
+	Partitioner string // partitioner of the producer, only reported
+
+	Headers     []string // static, random, unique, large or many
+	HeaderSize  int      // value size of the large header
+	HeaderCount int      // number of headers of the many mode
+
+	Source string // cluster and topic produced to
+	Target string // cluster and topic consumed from, replication is verified when set
+
+	ReportPath string
 }
 
 type Verifier struct {
+	cfg VerifierConfig
+
 	generatedRecords sync.Map
 
 	failedRecords     sync.Map
@@ -57,24 +128,61 @@ type Verifier struct {
 	successRecords    sync.Map
 
 	counts struct {
-		totalGenerated  int32
-		totalFailed     int32
-		totalInProgress int32
-		totalSuccess    int32
+		totalGenerated  int64
+		totalFailed     int64
+		totalInProgress int64
+		totalSuccess    int64
 	}
 
-	errs    sync.Mutex
-	errList []string
+	errs          sync.Mutex
+	errList       []string
+	droppedErrors int64 // errors not listed once a soak run reached soakMaxErrors
+
+	accounting *Accounting
+	lag        *lagMonitor
+	throughput *throughputStats
+	latency    *stats.Histogram
+	window     *window
+	started    time.Time
+	warmupEnd  int64 // unix nanos before which sent events are left out of the latency stats
+	canary     *canary
+	sent       *manifest.Writer
+	seen       *manifest.Writer
+
+	codec      codec.Codec
+	codecStats codecStats
+	evolution  *evolution
+	affinity   *affinity
+	headers    *headers
+	workers    int64 // partition consumers started
+
+	distribution *distribution
+	sizes        *sizes
+	checksums    *checksums
+
+	result *Report // of the completed run
+
+	acked       sync.Map // ack time of the records sent, when replicating
+	replication *stats.Histogram
 
 	consumer Consumer
 	producer Producer
+	source   Admin
+	admin    Admin
 	logger   Logger
 }
 
-func New(p Producer, c Consumer, l Logger) *Verifier {
-	return &Verifier{
+// New returns a verifier producing with p to the cluster administered by
+// source, and consuming with c from the cluster administered by a. Both
+// admins are the same unless replication is verified.
+func New(cfg VerifierConfig, p Producer, c Consumer, source, a Admin, l Logger) *Verifier {
+	v := &Verifier{
+		cfg: cfg,
+
 		consumer: c,
 		producer: p,
+		source:   source,
+		admin:    a,
 		logger:   l,
 
 		generatedRecords: sync.Map{},
@@ -84,32 +192,197 @@ func New(p Producer, c Consumer, l Logger) *Verifier {
 		successRecords:    sync.Map{},
 
 		counts: struct {
-			totalGenerated  int32
-			totalFailed     int32
-			totalInProgress int32
-			totalSuccess    int32
+			totalGenerated  int64
+			totalFailed     int64
+			totalInProgress int64
+			totalSuccess    int64
 		}{},
 
 		errs:    sync.Mutex{},
 		errList: []string{},
+
+		throughput: &throughputStats{warmup: cfg.Warmup},
+		latency:    stats.NewHistogram(),
+
+		replication: stats.NewHistogram(),
+
+		distribution: newDistribution(cfg.Partitioner),
+	}
+
+	v.codec = cfg.Codec
+	if v.codec == nil {
+		v.codec, _ = codec.New(codec.JSON)
+	}
+	v.evolution = newEvolution(v.codec)
+
+	if d := cfg.Size.Distribution; d != "" && d != load.NoPadding {
+		v.sizes = newSizes(d)
 	}
+
+	if cfg.SoakDuration > 0 {
+		v.window = newWindow(v, cfg.SoakWindow)
+	}
+
+	return v
 }
 
 func (v *Verifier) Verify() error {
 	v.logger.Info("starting producer-consumer verification")
-	err := v.startVerification()
-	if err != nil {
+
+	if err := v.openManifests(); err != nil {
+		return err
+	}
+	defer v.closeManifests()
+
+	if err := v.setupRecords(); err != nil {
+		return err
+	}
+
+	if err := v.consumer.Consume(v.partitionConsumer); err != nil {
+		v.logger.Error("starting the consumer")
+		return err
+	}
+
+	v.lag = newLagMonitor(v, v.cfg.LagInterval)
+	go v.lag.run()
+	defer v.lag.stop()
+
+	if err := v.startVerification(); err != nil {
 		return err
 	}
 
 	v.waitForCompletion()
+	v.lag.stop()
+
+	if v.window != nil {
+		v.window.sweep(time.Now())
+	}
+
 	v.logger.Error("producer-consumer verification completed")
 
 	// v.printResult()
 
+	return v.report()
+}
+
+// Produce only writes events, listing them in the manifest when configured,
+// so they can be consumed and verified separately.
+func (v *Verifier) Produce() error {
+	v.logger.Info("starting producer")
+
+	if err := v.openManifests(); err != nil {
+		return err
+	}
+	defer v.closeManifests()
+
+	if err := v.setupRecords(); err != nil {
+		return err
+	}
+
+	v.started = time.Now()
+
+	if err := v.produce(); err != nil {
+		return err
+	}
+
+	v.logger.Info("producing completed")
+
+	return v.report()
+}
+
+// Consume only reads events, listing them in the seen manifest when
+// configured, until no record is processed for IdleTimeout or the context is
+// done.
+func (v *Verifier) Consume(ctx context.Context) error {
+	v.logger.Info("starting consumer")
+
+	if err := v.openManifests(); err != nil {
+		return err
+	}
+	defer v.closeManifests()
+
+	if err := v.setupRecords(); err != nil {
+		return err
+	}
+
+	if err := v.consumer.Consume(v.partitionConsumer); err != nil {
+		v.logger.Error("starting the consumer")
+		return err
+	}
+
+	v.lag = newLagMonitor(v, v.cfg.LagInterval)
+	go v.lag.run()
+	defer v.lag.stop()
+
+	v.started = time.Now()
+
+	v.waitForIdle(ctx)
+	v.lag.stop()
+
+	v.logger.Info("consuming completed")
+
+	return v.report()
+}
+
+func (v *Verifier) openManifests() error {
+	var err error
+
+	if v.cfg.ManifestPath != "" && v.producer != nil {
+		if v.sent, err = manifest.Create(v.cfg.ManifestPath, v.cfg.ManifestFormat); err != nil {
+			v.logger.Errorf("creating manifest: %v", err)
+			return err
+		}
+	}
+
+	if v.cfg.SeenPath != "" && v.consumer != nil {
+		if v.seen, err = manifest.Create(v.cfg.SeenPath, v.cfg.ManifestFormat); err != nil {
+			v.logger.Errorf("creating seen manifest: %v", err)
+			return err
+		}
+	}
+
 	return nil
 }
 
+// setupRecords sets up the key strategy, keeping track of where the records
+// of every key are written to and consumed from, and the generated headers.
+func (v *Verifier) setupRecords() error {
+	var err error
+
+	// keys are only tracked up to a limit in soak mode, as the id and random
+	// strategies have one per record
+	keyLimit := 0
+	if v.window != nil {
+		keyLimit = soakMaxKeys
+	}
+	if v.affinity, err = newAffinity(v.cfg.KeyStrategy, v.cfg.HotKeys, keyLimit); err != nil {
+		v.logger.Errorf("setting up keys: %v", err)
+		return err
+	}
+
+	if v.headers, err = newHeaders(v.cfg.Headers, v.cfg.HeaderSize, v.cfg.HeaderCount); err != nil {
+		v.logger.Errorf("setting up headers: %v", err)
+		return err
+	}
+
+	if v.checksums, err = newChecksums(v.cfg.Checksum); err != nil {
+		v.logger.Errorf("setting up checksums: %v", err)
+	}
+
+	return err
+}
+
+func (v *Verifier) closeManifests() {
+	for _, m := range []*manifest.Writer{v.sent, v.seen} {
+		if m == nil {
+			continue
+		}
+		if err := m.Close(); err != nil {
+			v.logger.Errorf("closing manifest: %v", err)
+		}
+	}
+}
+
 func (v *Verifier) printResult() {
 	log.Printf("%d unexpected errors detected\n", len(v.errList))
 
@@ -140,75 +413,189 @@ func (v *Verifier) printResult() {
 	})
 }
 
-func (v *Verifier) partitionConsumer(res chan [][]byte) {
+func (v *Verifier) partitionConsumer(res chan []consumer.Record) {
 	go func() {
 		v.logger.AddedProcessor()
 		defer v.logger.RemovedProcessor()
 
+		worker := atomic.AddInt64(&v.workers, 1)
+
 		for msgs := range res {
 			for _, msg := range msgs {
 
-				var e Event
-				if err := json.Unmarshal(msg, &e); err != nil {
+				e, err := v.decode(msg.Value)
+				if err != nil {
 					v.addUnexpectedError(err.Error())
 					continue
 				}
+				if v.window.late(e) {
+					continue
+				}
+				if !v.checksums.check(e) {
+					// processed with the state it was sent with, so that it
+					// is reported as corrupted rather than lost as well
+					if st, ok := v.generatedRecords.Load(e.ID); ok {
+						v.storeProcessedRecord(e.ID, st.(string))
+					}
+					continue
+				}
+				v.evolution.check(msg.Value)
+				v.affinity.consumed(msg.Key, msg.Partition, worker)
+				v.headers.check(e.ID, msg.Headers)
+				v.sizes.consumed(len(msg.Value))
 
 				v.storeProcessedRecord(e.ID, e.State)
+				v.recordLatency(e)
+				v.recordReplication(e.ID)
+
+				if v.seen != nil {
+					if err := v.seen.Write(manifest.Entry{
+						ID:        e.ID,
+						State:     e.State,
+						Partition: msg.Partition,
+						Offset:    msg.Offset,
+						Timestamp: msg.Timestamp.UnixNano(),
+						Digest:    manifest.Digest(msg.Value),
+					}); err != nil {
+						v.addUnexpectedError(err.Error())
+					}
+				}
 			}
 		}
 	}()
 }
 
+// startVerification produces the load once the consumer and the lag monitor
+// are running.
 func (v *Verifier) startVerification() error {
-	err := v.consumer.Consume(v.partitionConsumer)
+	v.started = time.Now()
+
+	if v.window != nil {
+		quit := make(chan struct{})
+		defer close(quit)
+
+		go v.soak(quit)
+	}
+
+	return v.produce()
+}
+
+// produce sends the load, snapshotting the end offsets around it for the
+// broker-side accounting.
+func (v *Verifier) produce() error {
+	shape, err := load.NewShape(v.cfg.Shape)
+	if err != nil {
+		return err
+	}
+
+	size, err := load.NewSize(v.cfg.Size)
 	if err != nil {
-		v.logger.Error("starting the consumer")
 		return err
 	}
 
-	v.produceMessages()
+	before := v.snapshotEndOffsets()
+	v.distribution.setPartitions(len(before))
+	if v.cfg.Warmup > 0 {
+		atomic.StoreInt64(&v.warmupEnd, time.Now().Add(v.cfg.Warmup).UnixNano())
+	}
+	v.produceMessages(load.NewPacer(shape, v.cfg.Rate, v.cfg.ByteRate), size)
+	after := v.settledEndOffsets(before)
+
+	v.accounting = v.account(before, after)
 
 	return nil
 }
-func (v *Verifier) produceMessages() {
-	for i := 0; i < 1000; i++ {
+
+// producing tells whether another batch should be produced: until the soak
+// duration elapses in soak mode, or until all batches are sent otherwise.
+func (v *Verifier) producing(batch int) bool {
+	if v.window != nil {
+		return time.Since(v.started) < v.cfg.SoakDuration
+	}
+	return batch < v.cfg.Batches
+}
+
+func (v *Verifier) produceMessages(pacer *load.Pacer, payloadSize load.Size) {
+	for i := 0; v.producing(i); i++ {
 		ctx := context.Background()
 
-		payloads := make([][]byte, 1000)
+		// the batch waits before being built so that SentAt is not skewed by
+		// the wait, and is charged once built, for its actual bytes
+		if err := pacer.Wait(ctx); err != nil {
+			v.addUnexpectedError(err.Error())
+			return
+		}
+
+		msgs := make([]producer.Message, 0, v.cfg.BatchSize)
 		events := []Event{}
+		size := 0
 
-		for y := 0; y < 1_000; y++ {
+		for y := 0; y < v.cfg.BatchSize; y++ {
 			st := generateRandomState()
 			id := generateRandomID()
 
-			event := Event{ID: id, State: st}
+			event := Event{ID: id, State: st, SentAt: time.Now().UnixNano()}
 
-			payload, err := json.Marshal(event)
+			payload, err := v.encodePadded(&event, payloadSize())
 			if err != nil {
 				v.addUnexpectedError(err.Error())
 				continue
 			}
 
-			payloads = append(payloads, payload)
+			msgs = append(msgs, producer.Message{Key: v.affinity.key(id), Value: payload, Headers: v.headers.generate(id), OnAck: v.onAck(id)})
 			events = append(events, event)
+			size += len(payload)
 		}
 
-		err := v.producer.ProduceBatch(ctx, payloads)
+		pacer.Charge(len(msgs), size)
+
+		started, elapsed := time.Now(), pacer.Elapsed()
+
+		// the records of a failed batch may still be written, unless the batch
+		// was too large, so the ones acknowledged are stored regardless
+		acks, err := v.producer.ProduceBatch(ctx, msgs)
 		if err != nil {
+			v.logger.RecordProduceError()
 			v.addUnexpectedError(err.Error())
-			continue
+		} else {
+			v.throughput.record(started, elapsed, len(msgs), size)
 		}
 
-		for _, e := range events {
-			v.storeSentRecord(e.ID, e.State)
+		for i, e := range events {
+			v.sizes.produced(len(msgs[i].Value), acks[i].Err)
+			if acks[i].Err == nil {
+				v.storeSentRecord(e, msgs[i], acks[i])
+			}
 		}
+		v.logger.RecordDistribution(v.distribution.snapshot())
 
 	}
 }
-func (v *Verifier) storeSentRecord(id, st string) {
+func (v *Verifier) storeSentRecord(e Event, msg producer.Message, ack producer.Ack) {
+	id, st := e.ID, e.State
+
+	v.affinity.produced(msg.Key, ack.Partition)
+	v.distribution.record(msg.Key, ack.Partition)
+
+	if v.sent != nil {
+		err := v.sent.Write(manifest.Entry{
+			ID:        id,
+			State:     st,
+			Partition: ack.Partition,
+			Offset:    ack.Offset,
+			Timestamp: ack.Timestamp.UnixNano(),
+			Digest:    manifest.Digest(msg.Value),
+		})
+		if err != nil {
+			v.addUnexpectedError(err.Error())
+		}
+	}
+
 	v.generatedRecords.Store(id, st)
-	atomic.AddInt32(&v.counts.totalGenerated, 1)
+	if v.window != nil {
+		v.window.add(id)
+	}
+	atomic.AddInt64(&v.counts.totalGenerated, 1)
 	v.logger.RecordSent(st)
 }
 
@@ -218,13 +605,13 @@ func (v *Verifier) storeProcessedRecord(id, st string) {
 	switch st {
 	case Failed:
 		targetMap = &v.failedRecords
-		atomic.AddInt32(&v.counts.totalFailed, 1)
+		atomic.AddInt64(&v.counts.totalFailed, 1)
 	case InProgress:
 		targetMap = &v.inProgressRecords
-		atomic.AddInt32(&v.counts.totalInProgress, 1)
+		atomic.AddInt64(&v.counts.totalInProgress, 1)
 	case Success:
 		targetMap = &v.successRecords
-		atomic.AddInt32(&v.counts.totalSuccess, 1)
+		atomic.AddInt64(&v.counts.totalSuccess, 1)
 	default:
 		return
 	}
@@ -240,37 +627,48 @@ func (v *Verifier) storeProcessedRecord(id, st string) {
 	}
 }
 
-func (v *Verifier) addUnexpectedError(errMsg string) {
-	v.errs.Lock()
-	defer v.errs.Unlock()
-	v.errList = append(v.errList, errMsg)
+func (v *Verifier) brokerStats() []telemetry.BrokerStats {
+	var brokers []telemetry.BrokerStats
+	if v.producer != nil {
+		brokers = append(brokers, v.producer.BrokerStats()...)
+	}
+	if v.consumer != nil {
+		brokers = append(brokers, v.consumer.BrokerStats()...)
+	}
+	return brokers
 }
 
-func (v *Verifier) waitForCompletion() {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	v.logger.Info("waiting records to be processed")
-
-	tryCount := 0
-	maxTries := 60
+// recordLatency records the end-to-end latency of the event, unless it was
+// sent during the warm-up, which is excluded from stats like throughput is.
+func (v *Verifier) recordLatency(e Event) {
+	if e.SentAt == 0 || e.SentAt < atomic.LoadInt64(&v.warmupEnd) {
+		return
+	}
 
-	for range ticker.C {
-		if v.allMessagesProcessed() {
-			v.logger.Info("all records has been stored")
-			return
-		}
+	d := time.Since(time.Unix(0, e.SentAt))
+	v.latency.Observe(d)
+	v.logger.RecordLatency(d)
+}
 
-		tryCount++
-		if tryCount >= maxTries {
-			return
-		}
+func (v *Verifier) stateMap(st string) *sync.Map {
+	switch st {
+	case Failed:
+		return &v.failedRecords
+	case InProgress:
+		return &v.inProgressRecords
+	default:
+		return &v.successRecords
 	}
 }
 
-func (v *Verifier) allMessagesProcessed() bool {
-	totalProcessed := atomic.LoadInt32(&v.counts.totalFailed) + atomic.LoadInt32(&v.counts.totalInProgress) + atomic.LoadInt32(&v.counts.totalSuccess)
-	return atomic.LoadInt32(&v.counts.totalGenerated) == totalProcessed
+func (v *Verifier) addUnexpectedError(errMsg string) {
+	v.errs.Lock()
+	defer v.errs.Unlock()
+	if v.window != nil && len(v.errList) >= soakMaxErrors {
+		v.droppedErrors++
+		return
+	}
+	v.errList = append(v.errList, errMsg)
 }
 
 func generateRandomState() string {
@@ -0,0 +1,32 @@
+package verifier
+
+import (
+	"time"
+
+	"kafka-producer-consumer-tester/internal/pkg/telemetry"
+)
+
+// discard is a Logger dropping everything, for tests.
+type discard struct{}
+
+func (discard) RecordSent(string)                     {}
+func (discard) RecordProcessed(string)                {}
+func (discard) Info(string)                           {}
+func (discard) Error(string)                          {}
+func (discard) Infof(string, ...any)                  {}
+func (discard) Errorf(string, ...any)                 {}
+func (discard) RecordLag(map[int32]int64, int64)      {}
+func (discard) RecordDistribution(map[int32]int64)    {}
+func (discard) RecordBrokers([]telemetry.BrokerStats) {}
+func (discard) RecordLatency(time.Duration)           {}
+func (discard) RecordProduceError()                   {}
+func (discard) RecordProbeLost()                      {}
+func (discard) RecordAvailability(float64)            {}
+func (discard) AddedProcessor()                       {}
+func (discard) RemovedProcessor()                     {}
+
+// newTestVerifier returns a verifier with nothing but the configuration,
+// connected to no cluster.
+func newTestVerifier(cfg VerifierConfig) *Verifier {
+	return &Verifier{cfg: cfg, logger: discard{}}
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"kafka-producer-consumer-tester/internal/pkg/auth"
+)
+
+type Logger interface {
+	Info(string)
+	Infof(string, ...any)
+	Error(string)
+	Errorf(string, ...any)
+}
+
+// Admin wraps a kadm client scoped to the tested topic and consumer group.
+type Admin struct {
+	topic  string
+	group  string
+	client *kadm.Client
+	logger Logger
+}
+
+type AdminConfig struct {
+	Seeds []string
+	Topic string
+	Group string
+	Auth  auth.AuthConfig
+}
+
+func New(cfg AdminConfig, l Logger) (*Admin, error) {
+	l.Info("initializing admin client")
+
+	opts, err := auth.Opts(cfg.Auth)
+	if err != nil {
+		l.Errorf("configuring admin authentication: %v", err)
+		return nil, err
+	}
+
+	cl, err := kgo.NewClient(append(opts, kgo.SeedBrokers(cfg.Seeds...))...)
+	if err != nil {
+		l.Errorf("creating admin client: %v", err)
+		return nil, err
+	}
+	if err := cl.Ping(context.Background()); err != nil {
+		l.Errorf("verifying admin client connection: %v", err)
+		cl.Close()
+		return nil, err
+	}
+
+	return &Admin{client: kadm.NewClient(cl), topic: cfg.Topic, group: cfg.Group, logger: l}, nil
+}
+
+// EndOffsets returns the high watermark of every partition of the topic.
+func (a *Admin) EndOffsets(ctx context.Context) (map[int32]int64, error) {
+	listed, err := a.client.ListEndOffsets(ctx, a.topic)
+	if err != nil {
+		return nil, err
+	}
+
+	return offsetsOf(listed, a.topic)
+}
+
+// CommittedOffsets returns the offsets committed by the group for every
+// partition of the topic. Partitions without a commit are omitted.
+func (a *Admin) CommittedOffsets(ctx context.Context) (map[int32]int64, error) {
+	fetched, err := a.client.FetchOffsetsForTopics(ctx, a.group, a.topic)
+	if err != nil {
+		return nil, err
+	}
+	if err := fetched.Error(); err != nil {
+		return nil, err
+	}
+
+	offsets := map[int32]int64{}
+	fetched.Each(func(o kadm.OffsetResponse) {
+		if o.Topic == a.topic && o.At >= 0 {
+			offsets[o.Partition] = o.At
+		}
+	})
+
+	return offsets, nil
+}
+
+// CreateTopic creates the topic with the given number of partitions, and the
+// cluster's default replication factor. An existing topic is kept as long as it
+// has as many partitions.
+func (a *Admin) CreateTopic(ctx context.Context, partitions int32) error {
+	_, err := a.client.CreateTopic(ctx, partitions, -1, nil, a.topic)
+	if err == nil {
+		a.logger.Infof("created topic %s with %d partitions", a.topic, partitions)
+		return nil
+	}
+	if !errors.Is(err, kerr.TopicAlreadyExists) {
+		return err
+	}
+
+	details, err := a.client.ListTopics(ctx, a.topic)
+	if err != nil {
+		return err
+	}
+	if err := details.Error(); err != nil {
+		return err
+	}
+	if n := int32(len(details[a.topic].Partitions)); n != partitions {
+		return fmt.Errorf("topic %s already exists with %d partitions instead of %d", a.topic, n, partitions)
+	}
+
+	return nil
+}
+
+func (a *Admin) Shutdown() {
+	a.logger.Info("closing admin client")
+	a.client.Close()
+}
+
+func offsetsOf(listed kadm.ListedOffsets, topic string) (map[int32]int64, error) {
+	if err := listed.Error(); err != nil {
+		return nil, err
+	}
+
+	partitions, ok := listed[topic]
+	if !ok {
+		return nil, fmt.Errorf("topic %s not found", topic)
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	for p, o := range partitions {
+		offsets[p] = o.Offset
+	}
+
+	return offsets, nil
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+const (
+	Plain       = "plain"
+	ScramSha256 = "scram-sha-256"
+	ScramSha512 = "scram-sha-512"
+)
+
+// AuthConfig is how clients authenticate to a cluster. The zero value connects
+// in plaintext without authentication.
+type AuthConfig struct {
+	Mechanism string // SASL mechanism: plain, scram-sha-256 or scram-sha-512, none when empty
+	User      string
+	Password  string
+
+	TLS    bool
+	CAFile string // PEM bundle trusted instead of the system roots when set
+}
+
+// Opts returns the client options implementing the config.
+func Opts(cfg AuthConfig) ([]kgo.Opt, error) {
+	var opts []kgo.Opt
+
+	switch cfg.Mechanism {
+	case "":
+	case Plain:
+		opts = append(opts, kgo.SASL(plain.Auth{User: cfg.User, Pass: cfg.Password}.AsMechanism()))
+	case ScramSha256:
+		opts = append(opts, kgo.SASL(scram.Auth{User: cfg.User, Pass: cfg.Password}.AsSha256Mechanism()))
+	case ScramSha512:
+		opts = append(opts, kgo.SASL(scram.Auth{User: cfg.User, Pass: cfg.Password}.AsSha512Mechanism()))
+	default:
+		return nil, fmt.Errorf("unknown SASL mechanism %q", cfg.Mechanism)
+	}
+
+	if !cfg.TLS {
+		return opts, nil
+	}
+
+	tc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CAFile)
+		}
+	}
+
+	return append(opts, kgo.DialTLSConfig(tc)), nil
+}
@@ -0,0 +1,45 @@
+package codec
+
+import (
+	"github.com/hamba/avro/v2"
+)
+
+// eventSchema is the Avro schema of Event.
+const eventSchema = `{
+	"type": "record",
+	"name": "Event",
+	"namespace": "kafka_producer_consumer_tester",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "state", "type": "string"},
+		{"name": "sent_at", "type": "long", "default": 0},
+		{"name": "padding", "type": "string", "default": ""},
+		{"name": "checksum", "type": "string", "default": ""}
+	]
+}`
+
+type avroCodec struct {
+	schema avro.Schema
+}
+
+func newAvroCodec() (*avroCodec, error) {
+	s, err := avro.Parse(eventSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &avroCodec{schema: s}, nil
+}
+
+func (*avroCodec) Name() string { return Avro }
+
+func (*avroCodec) Schema() (string, string) { return SchemaAvro, eventSchema }
+
+func (c *avroCodec) Encode(e Event) ([]byte, error) {
+	return avro.Marshal(c.schema, e)
+}
+
+func (c *avroCodec) Decode(b []byte) (e Event, err error) {
+	err = avro.Unmarshal(c.schema, b, &e)
+	return
+}
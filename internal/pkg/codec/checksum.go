@@ -0,0 +1,57 @@
+package codec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"strings"
+)
+
+// Checksum algorithms.
+const (
+	NoChecksum = "none"
+	CRC32C     = "crc32c"
+	SHA256     = "sha256"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Sum returns the checksum of the event body, every field but the checksum
+// itself, prefixed by the algorithm, e.g. crc32c:8a9136aa. It doesn't depend
+// on the codec, so the values can be re-encoded on the way.
+func Sum(algorithm string, e Event) (string, error) {
+	var h hash.Hash
+	switch algorithm {
+	case CRC32C:
+		h = crc32.New(castagnoli)
+	case SHA256:
+		h = sha256.New()
+	default:
+		return "", fmt.Errorf("unknown checksum algorithm %q", algorithm)
+	}
+
+	var buf [binary.MaxVarintLen64]byte
+	for _, s := range []string{e.ID, e.State, e.Padding} {
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))])
+		h.Write([]byte(s))
+	}
+	binary.BigEndian.PutUint64(buf[:8], uint64(e.SentAt))
+	h.Write(buf[:8])
+
+	return algorithm + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify tells whether the event carries a checksum matching its body. It
+// returns false for unknown algorithms, which can't be verified either.
+func Verify(e Event) bool {
+	algorithm, _, ok := strings.Cut(e.Checksum, ":")
+	if !ok {
+		return false
+	}
+
+	sum, err := Sum(algorithm, e)
+	return err == nil && sum == e.Checksum
+}
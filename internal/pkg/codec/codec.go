@@ -0,0 +1,59 @@
+package codec
+
+import (
+	"fmt"
+)
+
+const (
+	JSON        = "json"
+	Avro        = "avro"
+	Protobuf    = "protobuf"
+	MessagePack = "msgpack"
+)
+
+// Event is the payload produced and consumed by the tester.
+type Event struct {
+	ID     string `avro:"id" msgpack:"id"`
+	State  string `avro:"state" msgpack:"state"`
+	SentAt int64  `json:",omitempty" avro:"sent_at" msgpack:"sent_at,omitempty"` // unix nanoseconds, used to measure end-to-end latency
+
+	Padding  string `json:",omitempty" avro:"padding" msgpack:"padding,omitempty"`   // filler bringing the payload to the configured size
+	Checksum string `json:",omitempty" avro:"checksum" msgpack:"checksum,omitempty"` // of the other fields, see Sum
+}
+
+// Codec serializes events into record values.
+type Codec interface {
+	Name() string
+	Encode(Event) ([]byte, error)
+	Decode([]byte) (Event, error)
+}
+
+// Schema types as named by schema registries.
+const (
+	SchemaAvro     = "AVRO"
+	SchemaProtobuf = "PROTOBUF"
+	SchemaJSON     = "JSON"
+)
+
+// SchemaCodec is implemented by the codecs whose events are described by a
+// schema a registry can hold.
+type SchemaCodec interface {
+	Codec
+	Schema() (typ string, schema string)
+}
+
+// New returns the codec with the given name.
+func New(name string) (Codec, error) {
+	switch name {
+	case JSON:
+		return jsonCodec{}, nil
+	case Avro:
+		return newAvroCodec()
+	case Protobuf:
+		return protobufCodec{}, nil
+	case MessagePack:
+		return msgpackCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+}
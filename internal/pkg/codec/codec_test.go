@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	events := []Event{
+		{ID: "id", State: "success"},
+		{ID: "id", State: "failed", SentAt: 1_700_000_000_000_000_000},
+		{ID: "id", State: "success", SentAt: 42, Padding: strings.Repeat("x", 100), Checksum: "crc32c:00000000"},
+		{},
+	}
+
+	for _, name := range []string{JSON, Avro, Protobuf, MessagePack} {
+		c, err := New(name)
+		if err != nil {
+			t.Fatalf("creating the %s codec: %v", name, err)
+		}
+		if c.Name() != name {
+			t.Errorf("codec %s is named %s", name, c.Name())
+		}
+
+		for _, e := range events {
+			b, err := c.Encode(e)
+			if err != nil {
+				t.Fatalf("%s: encoding %+v: %v", name, e, err)
+			}
+
+			got, err := c.Decode(b)
+			if err != nil {
+				t.Fatalf("%s: decoding %+v: %v", name, e, err)
+			}
+			if got != e {
+				t.Errorf("%s: got %+v, want %+v", name, got, e)
+			}
+		}
+	}
+}
+
+func TestNewUnknown(t *testing.T) {
+	if _, err := New("xml"); err == nil {
+		t.Error("creating an unknown codec: got no error")
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	e := Event{ID: "id", State: "success", SentAt: 42, Padding: "xx"}
+
+	for _, algorithm := range []string{CRC32C, SHA256} {
+		sum, err := Sum(algorithm, e)
+		if err != nil {
+			t.Fatalf("summing with %s: %v", algorithm, err)
+		}
+		if !strings.HasPrefix(sum, algorithm+":") {
+			t.Errorf("%s checksum %s is not prefixed by the algorithm", algorithm, sum)
+		}
+
+		signed := e
+		signed.Checksum = sum
+		if !Verify(signed) {
+			t.Errorf("%s: the checksum of an untouched event doesn't verify", algorithm)
+		}
+
+		for _, tampered := range []Event{
+			{ID: "other", State: e.State, SentAt: e.SentAt, Padding: e.Padding},
+			{ID: e.ID, State: "failed", SentAt: e.SentAt, Padding: e.Padding},
+			{ID: e.ID, State: e.State, SentAt: 43, Padding: e.Padding},
+			{ID: e.ID, State: e.State, SentAt: e.SentAt, Padding: "x"},
+			{ID: e.ID + e.State[:1], State: e.State[1:], SentAt: e.SentAt, Padding: e.Padding}, // same bytes, other boundaries
+		} {
+			tampered.Checksum = sum
+			if Verify(tampered) {
+				t.Errorf("%s: the checksum verifies the tampered event %+v", algorithm, tampered)
+			}
+		}
+	}
+
+	if _, err := Sum(NoChecksum, e); err == nil {
+		t.Error("summing without algorithm: got no error")
+	}
+	for _, checksum := range []string{"", "00000000", "md5:00000000"} {
+		e.Checksum = checksum
+		if Verify(e) {
+			t.Errorf("checksum %q verifies", checksum)
+		}
+	}
+}
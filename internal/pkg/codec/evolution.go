@@ -0,0 +1,424 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
+
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// envelopeMagic starts the values written by the evolution codec. It is
+// followed by the version of the writer schema.
+const envelopeMagic = 'V'
+
+const (
+	fieldNameID       = "id"
+	fieldNameState    = "state"
+	fieldNameSentAt   = "sent_at"
+	fieldNameMetadata = "metadata"
+)
+
+const fieldMetadata protowire.Number = 4
+
+// version is one of the Event schema versions. v2 adds sent_at without a
+// default, which readers of v1 and v3 values can't fill in, and v3 removes it
+// again: both are the kind of changes the compatibility matrix should catch.
+type version struct {
+	name     string
+	fields   []string
+	required []string // fields readers of this version have no default for
+	avro     avro.Schema
+}
+
+var versions = []*version{
+	{
+		name:     "v1",
+		fields:   []string{fieldNameID, fieldNameState},
+		required: []string{fieldNameID, fieldNameState},
+		avro: avro.MustParse(`{"type": "record", "name": "Event", "namespace": "kafka_producer_consumer_tester", "fields": [
+			{"name": "id", "type": "string"},
+			{"name": "state", "type": "string"}
+		]}`),
+	},
+	{
+		name:     "v2",
+		fields:   []string{fieldNameID, fieldNameState, fieldNameSentAt, fieldNameMetadata},
+		required: []string{fieldNameID, fieldNameState, fieldNameSentAt},
+		avro: avro.MustParse(`{"type": "record", "name": "Event", "namespace": "kafka_producer_consumer_tester", "fields": [
+			{"name": "id", "type": "string"},
+			{"name": "state", "type": "string"},
+			{"name": "sent_at", "type": "long"},
+			{"name": "metadata", "type": {"type": "map", "values": "string"}, "default": {}}
+		]}`),
+	},
+	{
+		name:     "v3",
+		fields:   []string{fieldNameID, fieldNameState, fieldNameMetadata},
+		required: []string{fieldNameID, fieldNameState},
+		avro: avro.MustParse(`{"type": "record", "name": "Event", "namespace": "kafka_producer_consumer_tester", "fields": [
+			{"name": "id", "type": "string"},
+			{"name": "state", "type": "string"},
+			{"name": "metadata", "type": {"type": "map", "values": "string"}, "default": {}}
+		]}`),
+	},
+}
+
+// Versions returns the names of the Event schema versions.
+func Versions() []string {
+	names := make([]string, 0, len(versions))
+	for _, v := range versions {
+		names = append(names, v.name)
+	}
+	return names
+}
+
+// Evolving is implemented by codecs writing several schema versions, checking
+// how each of them is read by the readers of every version.
+type Evolving interface {
+	Codec
+	Readers() []string
+
+	// Check decodes the value with the reader version, returning the writer
+	// version and an error when the reader fails or doesn't read the fields it
+	// shares with the writer as written.
+	Check(reader string, b []byte) (writer string, err error)
+}
+
+// record holds the fields of every version.
+type record struct {
+	ID       string            `avro:"id" json:"id"`
+	State    string            `avro:"state" json:"state"`
+	SentAt   int64             `avro:"sent_at" json:"sent_at"`
+	Metadata map[string]string `avro:"metadata" json:"metadata"`
+}
+
+// Evolution writes events with the writer versions in turn, framed in an
+// envelope telling the version apart, and decodes them with the writer
+// version itself.
+type Evolution struct {
+	format  string
+	writers []*version
+	readers []*version
+	next    atomic.Uint64
+
+	resolved map[[2]*version]resolution
+}
+
+// resolution decodes Avro values written with one version as another reads
+// them. Each has its own API since resolved schemas share the fingerprint of
+// the writer schema, and so would share their cached decoders.
+type resolution struct {
+	schema avro.Schema
+	api    avro.API
+	err    error
+}
+
+// NewEvolution returns an evolution codec for the avro, json or protobuf
+// format. Every version is written, or read, when none is given.
+func NewEvolution(format string, writers, readers []string) (*Evolution, error) {
+	if format != Avro && format != JSON && format != Protobuf {
+		return nil, fmt.Errorf("schema evolution is not supported by the %s codec", format)
+	}
+	if len(writers) == 0 {
+		writers = Versions()
+	}
+	if len(readers) == 0 {
+		readers = Versions()
+	}
+
+	c := &Evolution{format: format}
+	for _, name := range writers {
+		v, err := versionOf(name)
+		if err != nil {
+			return nil, err
+		}
+		c.writers = append(c.writers, v)
+	}
+	for _, name := range readers {
+		v, err := versionOf(name)
+		if err != nil {
+			return nil, err
+		}
+		c.readers = append(c.readers, v)
+	}
+
+	c.resolved = map[[2]*version]resolution{}
+	for _, w := range versions {
+		for _, r := range versions {
+			s, err := avro.NewSchemaCompatibility().Resolve(r.avro, w.avro)
+			c.resolved[[2]*version{w, r}] = resolution{schema: s, api: avro.Config{}.Freeze(), err: err}
+		}
+	}
+
+	return c, nil
+}
+
+func (c *Evolution) Name() string { return c.format + "+evolution" }
+
+func (c *Evolution) Readers() []string {
+	names := make([]string, 0, len(c.readers))
+	for _, v := range c.readers {
+		names = append(names, v.name)
+	}
+	return names
+}
+
+func (c *Evolution) Encode(e Event) ([]byte, error) {
+	w := c.writers[(c.next.Add(1)-1)%uint64(len(c.writers))]
+
+	r := record{ID: e.ID, State: e.State, SentAt: e.SentAt, Metadata: map[string]string{"writer": w.name}}
+
+	b, err := c.encode(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{envelopeMagic, byte(slices.Index(versions, w) + 1)}, b...), nil
+}
+
+func (c *Evolution) Decode(b []byte) (Event, error) {
+	w, payload, err := unwrap(b)
+	if err != nil {
+		return Event{}, err
+	}
+
+	r, err := c.decode(w, w, payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{ID: r.ID, State: r.State, SentAt: r.SentAt}, nil
+}
+
+func (c *Evolution) Check(reader string, b []byte) (string, error) {
+	w, payload, err := unwrap(b)
+	if err != nil {
+		return "", err
+	}
+
+	rv, err := versionOf(reader)
+	if err != nil {
+		return w.name, err
+	}
+
+	written, err := c.decode(w, w, payload)
+	if err != nil {
+		return w.name, err
+	}
+
+	read, err := c.decode(w, rv, payload)
+	if err != nil {
+		return w.name, err
+	}
+
+	for _, f := range rv.fields {
+		if slices.Contains(w.fields, f) && !equal(f, written, read) {
+			return w.name, fmt.Errorf("%s read as another value than written", f)
+		}
+	}
+
+	return w.name, nil
+}
+
+func (c *Evolution) encode(w *version, r record) ([]byte, error) {
+	switch c.format {
+	case Avro:
+		return avro.Marshal(w.avro, r)
+	case JSON:
+		fields := map[string]any{}
+		for _, f := range w.fields {
+			fields[f] = valueOf(f, r)
+		}
+		return json.Marshal(fields)
+	default:
+		return encodeProtobuf(w, r), nil
+	}
+}
+
+// decode reads a value written with the w version as the reader version r
+// sees it.
+func (c *Evolution) decode(w, r *version, b []byte) (rec record, err error) {
+	switch c.format {
+	case Avro:
+		res := c.resolved[[2]*version{w, r}]
+		if res.err != nil {
+			return rec, res.err
+		}
+		err = res.api.Unmarshal(res.schema, b, &rec)
+		return rec, err
+	case JSON:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return rec, err
+		}
+		for _, f := range r.required {
+			if _, ok := fields[f]; !ok {
+				return rec, fmt.Errorf("missing required field %s", f)
+			}
+		}
+		for f := range fields {
+			if !slices.Contains(r.fields, f) {
+				delete(fields, f)
+			}
+		}
+		known, err := json.Marshal(fields)
+		if err != nil {
+			return rec, err
+		}
+		err = json.Unmarshal(known, &rec)
+		return rec, err
+	default:
+		return decodeProtobuf(r, b)
+	}
+}
+
+func encodeProtobuf(w *version, r record) []byte {
+	var b []byte
+
+	for _, f := range w.fields {
+		switch f {
+		case fieldNameID:
+			b = protowire.AppendTag(b, fieldID, protowire.BytesType)
+			b = protowire.AppendString(b, r.ID)
+		case fieldNameState:
+			b = protowire.AppendTag(b, fieldState, protowire.BytesType)
+			b = protowire.AppendString(b, r.State)
+		case fieldNameSentAt:
+			b = protowire.AppendTag(b, fieldSentAt, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(r.SentAt))
+		case fieldNameMetadata:
+			keys := make([]string, 0, len(r.Metadata))
+			for k := range r.Metadata {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+
+			for _, k := range keys {
+				var entry []byte
+				entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+				entry = protowire.AppendString(entry, k)
+				entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+				entry = protowire.AppendString(entry, r.Metadata[k])
+
+				b = protowire.AppendTag(b, fieldMetadata, protowire.BytesType)
+				b = protowire.AppendBytes(b, entry)
+			}
+		}
+	}
+
+	return b
+}
+
+// decodeProtobuf reads the fields known to the reader version, skipping the
+// others. Being proto3, no field is required.
+func decodeProtobuf(r *version, b []byte) (rec record, err error) {
+	knows := func(f string) bool { return slices.Contains(r.fields, f) }
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return rec, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch {
+		case num == fieldID && typ == protowire.BytesType && knows(fieldNameID):
+			rec.ID, n = protowire.ConsumeString(b)
+		case num == fieldState && typ == protowire.BytesType && knows(fieldNameState):
+			rec.State, n = protowire.ConsumeString(b)
+		case num == fieldSentAt && typ == protowire.VarintType && knows(fieldNameSentAt):
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			rec.SentAt = int64(v)
+		case num == fieldMetadata && typ == protowire.BytesType && knows(fieldNameMetadata):
+			var entry []byte
+			entry, n = protowire.ConsumeBytes(b)
+			if n >= 0 {
+				if rec.Metadata == nil {
+					rec.Metadata = map[string]string{}
+				}
+				if err := decodeEntry(entry, rec.Metadata); err != nil {
+					return rec, err
+				}
+			}
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return rec, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+
+	return rec, nil
+}
+
+func decodeEntry(b []byte, m map[string]string) error {
+	var k, v string
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			k, n = protowire.ConsumeString(b)
+		case num == 2 && typ == protowire.BytesType:
+			v, n = protowire.ConsumeString(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+
+	m[k] = v
+	return nil
+}
+
+func unwrap(b []byte) (*version, []byte, error) {
+	if len(b) < 2 || b[0] != envelopeMagic || int(b[1]) < 1 || int(b[1]) > len(versions) {
+		return nil, nil, errors.New("value is not in a versioned envelope")
+	}
+
+	return versions[b[1]-1], b[2:], nil
+}
+
+func versionOf(name string) (*version, error) {
+	for _, v := range versions {
+		if v.name == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown schema version %q", name)
+}
+
+func valueOf(field string, r record) any {
+	switch field {
+	case fieldNameID:
+		return r.ID
+	case fieldNameState:
+		return r.State
+	case fieldNameSentAt:
+		return r.SentAt
+	default:
+		return r.Metadata
+	}
+}
+
+func equal(field string, a, b record) bool {
+	if field == fieldNameMetadata {
+		return maps.Equal(a.Metadata, b.Metadata)
+	}
+	return valueOf(field, a) == valueOf(field, b)
+}
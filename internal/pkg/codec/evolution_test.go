@@ -0,0 +1,127 @@
+package codec
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEvolutionRoundTrip(t *testing.T) {
+	tests := []struct {
+		writer string
+		want   Event
+	}{
+		{"v1", Event{ID: "id", State: "success"}}, // v1 has no sent_at
+		{"v2", Event{ID: "id", State: "success", SentAt: 42}},
+		{"v3", Event{ID: "id", State: "success"}}, // v3 removed it
+	}
+
+	for _, format := range []string{Avro, JSON, Protobuf} {
+		for _, tt := range tests {
+			c, err := NewEvolution(format, []string{tt.writer}, nil)
+			if err != nil {
+				t.Fatalf("creating the %s codec: %v", format, err)
+			}
+
+			b, err := c.Encode(Event{ID: "id", State: "success", SentAt: 42})
+			if err != nil {
+				t.Fatalf("%s %s: encoding: %v", format, tt.writer, err)
+			}
+
+			got, err := c.Decode(b)
+			if err != nil {
+				t.Fatalf("%s %s: decoding: %v", format, tt.writer, err)
+			}
+			if got != tt.want {
+				t.Errorf("%s %s: got %+v, want %+v", format, tt.writer, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestEvolutionWritersInTurn(t *testing.T) {
+	c, err := NewEvolution(JSON, []string{"v3", "v1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{"v3", "v1", "v3", "v1"} {
+		b, err := c.Encode(Event{ID: "id", State: "success"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		writer, err := c.Check("v1", b)
+		if err != nil {
+			t.Fatalf("checking value %d: %v", i, err)
+		}
+		if writer != want {
+			t.Errorf("value %d written with %s, want %s", i, writer, want)
+		}
+	}
+}
+
+// TestEvolutionMatrix checks which readers fail on which writers: v2 makes
+// sent_at required without default, so Avro and JSON readers of v2 can't read
+// what v1 and v3 wrote, while proto3 has no required fields at all.
+func TestEvolutionMatrix(t *testing.T) {
+	failing := map[string]map[[2]string]bool{
+		Avro:     {{"v1", "v2"}: true, {"v3", "v2"}: true},
+		JSON:     {{"v1", "v2"}: true, {"v3", "v2"}: true},
+		Protobuf: {},
+	}
+
+	for format, fails := range failing {
+		for _, writer := range Versions() {
+			c, err := NewEvolution(format, []string{writer}, nil)
+			if err != nil {
+				t.Fatalf("creating the %s codec: %v", format, err)
+			}
+
+			b, err := c.Encode(Event{ID: "id", State: "success", SentAt: 42})
+			if err != nil {
+				t.Fatalf("%s %s: encoding: %v", format, writer, err)
+			}
+
+			for _, reader := range c.Readers() {
+				t.Run(fmt.Sprintf("%s/%s-%s", format, writer, reader), func(t *testing.T) {
+					got, err := c.Check(reader, b)
+					if got != writer {
+						t.Errorf("checked as written with %s, want %s", got, writer)
+					}
+					if wantErr := fails[[2]string{writer, reader}]; (err != nil) != wantErr {
+						t.Errorf("got error %v, want one: %t", err, wantErr)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestNewEvolutionErrors(t *testing.T) {
+	tests := []struct {
+		format           string
+		writers, readers []string
+	}{
+		{MessagePack, nil, nil},
+		{Avro, []string{"v4"}, nil},
+		{Avro, nil, []string{"v0"}},
+	}
+	for _, tt := range tests {
+		if _, err := NewEvolution(tt.format, tt.writers, tt.readers); err == nil {
+			t.Errorf("creating a %s codec writing %v and reading %v: got no error", tt.format, tt.writers, tt.readers)
+		}
+	}
+}
+
+func TestEvolutionNotEnveloped(t *testing.T) {
+	c, err := NewEvolution(JSON, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range [][]byte{nil, {'V'}, {'V', 0}, {'V', 4}, []byte(`{"id":"x"}`)} {
+		if _, err := c.Decode(b); err == nil {
+			t.Errorf("decoding % x: got no error", b)
+		}
+	}
+}
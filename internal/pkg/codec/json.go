@@ -0,0 +1,35 @@
+package codec
+
+import (
+	"encoding/json"
+)
+
+// eventJSONSchema is the JSON schema of Event.
+const eventJSONSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"title": "Event",
+	"type": "object",
+	"properties": {
+		"ID": {"type": "string"},
+		"State": {"type": "string"},
+		"SentAt": {"type": "integer"},
+		"Padding": {"type": "string"},
+		"Checksum": {"type": "string"}
+	},
+	"required": ["ID", "State"]
+}`
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return JSON }
+
+func (jsonCodec) Schema() (string, string) { return SchemaJSON, eventJSONSchema }
+
+func (jsonCodec) Encode(e Event) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func (jsonCodec) Decode(b []byte) (e Event, err error) {
+	err = json.Unmarshal(b, &e)
+	return
+}
@@ -0,0 +1,18 @@
+package codec
+
+import (
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return MessagePack }
+
+func (msgpackCodec) Encode(e Event) ([]byte, error) {
+	return msgpack.Marshal(e)
+}
+
+func (msgpackCodec) Decode(b []byte) (e Event, err error) {
+	err = msgpack.Unmarshal(b, &e)
+	return
+}
@@ -0,0 +1,98 @@
+package codec
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// eventProto defines the Event message. It is small enough to be encoded by
+// hand rather than generated.
+const eventProto = `syntax = "proto3";
+
+package kafka_producer_consumer_tester;
+
+message Event {
+  string id = 1;
+  string state = 2;
+  int64 sent_at = 3;
+  string padding = 5;
+  string checksum = 6;
+}
+`
+
+// Field numbers of the Event message.
+const (
+	fieldID     protowire.Number = 1
+	fieldState  protowire.Number = 2
+	fieldSentAt protowire.Number = 3
+
+	// 4 is the metadata of the evolution versions
+	fieldPadding  protowire.Number = 5
+	fieldChecksum protowire.Number = 6
+)
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return Protobuf }
+
+func (protobufCodec) Schema() (string, string) { return SchemaProtobuf, eventProto }
+
+func (protobufCodec) Encode(e Event) ([]byte, error) {
+	b := make([]byte, 0, len(e.ID)+len(e.State)+len(e.Padding)+len(e.Checksum)+32)
+
+	if e.ID != "" {
+		b = protowire.AppendTag(b, fieldID, protowire.BytesType)
+		b = protowire.AppendString(b, e.ID)
+	}
+	if e.State != "" {
+		b = protowire.AppendTag(b, fieldState, protowire.BytesType)
+		b = protowire.AppendString(b, e.State)
+	}
+	if e.SentAt != 0 {
+		b = protowire.AppendTag(b, fieldSentAt, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(e.SentAt))
+	}
+	if e.Padding != "" {
+		b = protowire.AppendTag(b, fieldPadding, protowire.BytesType)
+		b = protowire.AppendString(b, e.Padding)
+	}
+	if e.Checksum != "" {
+		b = protowire.AppendTag(b, fieldChecksum, protowire.BytesType)
+		b = protowire.AppendString(b, e.Checksum)
+	}
+
+	return b, nil
+}
+
+// Decode skips unknown fields, as protobuf readers do.
+func (protobufCodec) Decode(b []byte) (e Event, err error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return e, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch {
+		case num == fieldID && typ == protowire.BytesType:
+			e.ID, n = protowire.ConsumeString(b)
+		case num == fieldState && typ == protowire.BytesType:
+			e.State, n = protowire.ConsumeString(b)
+		case num == fieldSentAt && typ == protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(b)
+			e.SentAt = int64(v)
+		case num == fieldPadding && typ == protowire.BytesType:
+			e.Padding, n = protowire.ConsumeString(b)
+		case num == fieldChecksum && typ == protowire.BytesType:
+			e.Checksum, n = protowire.ConsumeString(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return e, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+
+	return e, nil
+}
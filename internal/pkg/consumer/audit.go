@@ -0,0 +1,83 @@
+package consumer
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// CommitAudit is a single CommitRecords call made by a partition consumer.
+type CommitAudit struct {
+	Partition int32     `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Timestamp time.Time `json:"timestamp"`
+	Err       string    `json:"error,omitempty"`
+}
+
+// audit keeps track of the offsets delivered to the partition consumers and of
+// every commit they make. When a file is given, each commit is appended to it
+// as a JSON line; only failed commits are kept in memory.
+type audit struct {
+	mu sync.Mutex
+
+	file *os.File
+	enc  *json.Encoder
+
+	commits   int
+	failed    []CommitAudit
+	delivered map[int32]int64
+}
+
+func newAudit(path string) (*audit, error) {
+	a := &audit{delivered: map[int32]int64{}}
+
+	if path == "" {
+		return a, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	a.file = f
+	a.enc = json.NewEncoder(f)
+
+	return a, nil
+}
+
+// recordDelivered stores the offset following the last record handed over for
+// processing, which is what a commit of that record is expected to be.
+func (a *audit) recordDelivered(partition int32, next int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.delivered[partition] = next
+}
+
+func (a *audit) recordCommit(partition int32, offset int64, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry := CommitAudit{Partition: partition, Offset: offset, Timestamp: time.Now()}
+	if err != nil {
+		entry.Err = err.Error()
+		a.failed = append(a.failed, entry)
+	}
+	a.commits++
+
+	if a.enc != nil {
+		_ = a.enc.Encode(entry)
+	}
+}
+
+func (a *audit) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file != nil {
+		a.file.Close()
+		a.file, a.enc = nil, nil
+	}
+}
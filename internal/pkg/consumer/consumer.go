@@ -5,6 +5,10 @@ import (
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/plugin/kotel"
+
+	"kafka-producer-consumer-tester/internal/pkg/auth"
+	"kafka-producer-consumer-tester/internal/pkg/telemetry"
 )
 
 type Logger interface {
@@ -13,6 +17,8 @@ type Logger interface {
 	Error(string)
 	Errorf(string, ...any)
 
+	RecordCommitError()
+
 	AddedPartition()
 	RemovedPartition()
 }
@@ -21,56 +27,164 @@ type Consumer struct {
 	Seeds      []string
 	Topic      string
 	Group      string
+	auth       auth.AuthConfig
 	logger     Logger
 	processors []*processor
+
+	auditPath string
+	audit     *audit
+	telemetry *telemetry.Telemetry
+	tracer    *kotel.Tracer
+
+	fetchMinBytes          int32
+	fetchMaxBytes          int32
+	fetchMaxPartitionBytes int32
+	fetchMaxWait           time.Duration
+
+	members int
 }
 
 type ConsumerConfig struct {
 	Seeds []string
 	Topic string
 	Group string
+	Auth  auth.AuthConfig
+
+	CommitAuditPath string // every commit is appended here as a JSON line when set
+
+	FetchMinBytes          int32         // fetch response size waited for, ~1MB when 0
+	FetchMaxBytes          int32         // largest fetch response, ~2MB when 0
+	FetchMaxPartitionBytes int32         // largest fetch response per partition, 1MiB when 0
+	FetchMaxWait           time.Duration // longest wait for FetchMinBytes, 5s when 0
+
+	Members int // group members started, each with its own client; 1 when 0
+
+	Tracer *kotel.Tracer // extracts the traceparent header and emits process spans when set
 }
 
 func New(cfg ConsumerConfig, l Logger) *Consumer {
-	return &Consumer{Seeds: cfg.Seeds, Group: cfg.Group, Topic: cfg.Topic, logger: l}
+	return &Consumer{Seeds: cfg.Seeds, Group: cfg.Group, Topic: cfg.Topic, auth: cfg.Auth, logger: l, auditPath: cfg.CommitAuditPath, tracer: cfg.Tracer, fetchMinBytes: cfg.FetchMinBytes, fetchMaxBytes: cfg.FetchMaxBytes, fetchMaxPartitionBytes: cfg.FetchMaxPartitionBytes, fetchMaxWait: cfg.FetchMaxWait, members: cfg.Members}
 }
 
-func (c *Consumer) Consume(callback func(chan [][]byte)) error {
+func (c *Consumer) Consume(callback func(chan []Record)) error {
 	c.logger.Info("initializing consumer")
 
-	p := newProcessor(callback, c.logger)
+	authOpts, err := auth.Opts(c.auth)
+	if err != nil {
+		c.logger.Errorf("configuring consumer authentication: %v", err)
+		return err
+	}
+
+	a, err := newAudit(c.auditPath)
+	if err != nil {
+		c.logger.Errorf("opening commit audit file: %v", err)
+		return err
+	}
+	c.audit = a
+
+	c.telemetry = telemetry.New("consumer")
+
+	for range max(c.members, 1) {
+		if err := c.join(callback, authOpts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// join starts a member of the group, sharing the audit and telemetry of the
+// others.
+func (c *Consumer) join(callback func(chan []Record), authOpts []kgo.Opt) error {
+	p := newProcessor(callback, c.audit, c.tracer, c.logger)
 
-	cl, err := kgo.NewClient(
+	opts := []kgo.Opt{
 		kgo.SeedBrokers(c.Seeds...),
+		kgo.WithHooks(c.telemetry),
 		kgo.ConsumeTopics(c.Topic),
 		kgo.ConsumerGroup(c.Group),
 
-		kgo.FetchMinBytes(1_000_000),    // Set minimum fetch bytes to ~1MB
-		kgo.FetchMaxBytes(2_000_000),    // Set maximum fetch bytes to ~2MB
-		kgo.FetchMaxWait(5*time.Second), // Wait up to 5 seconds if fetch.min.bytes not reached
-
 		kgo.OnPartitionsAssigned(p.assigned),
 		kgo.OnPartitionsRevoked(p.lostOrRevoked),
 		kgo.OnPartitionsLost(p.lostOrRevoked),
 		kgo.BlockRebalanceOnPoll(),
-	)
+	}
+	if c.fetchMinBytes > 0 {
+		opts = append(opts, kgo.FetchMinBytes(c.fetchMinBytes))
+	} else {
+		opts = append(opts, kgo.FetchMinBytes(1_000_000)) // Set minimum fetch bytes to ~1MB
+	}
+	if c.fetchMaxWait > 0 {
+		opts = append(opts, kgo.FetchMaxWait(c.fetchMaxWait))
+	} else {
+		opts = append(opts, kgo.FetchMaxWait(5*time.Second)) // Wait up to 5 seconds if fetch.min.bytes not reached
+	}
+	if c.fetchMaxBytes > 0 {
+		opts = append(opts, kgo.FetchMaxBytes(c.fetchMaxBytes))
+	} else {
+		opts = append(opts, kgo.FetchMaxBytes(2_000_000)) // Set maximum fetch bytes to ~2MB
+	}
+	if c.fetchMaxPartitionBytes > 0 {
+		opts = append(opts, kgo.FetchMaxPartitionBytes(c.fetchMaxPartitionBytes))
+	}
+	opts = append(opts, authOpts...)
+	if c.tracer != nil {
+		opts = append(opts, kgo.WithHooks(kotel.NewKotel(kotel.WithTracer(c.tracer)).Hooks()...))
+	}
+
+	cl, err := kgo.NewClient(opts...)
 	if err != nil {
 		c.logger.Errorf("creating consumer client: %v", err)
 		return err
 	}
 	if err := cl.Ping(context.Background()); err != nil {
 		c.logger.Errorf("verifying consumer client connection: %v", err)
+		cl.Close()
 		return err
 	}
 
+	c.processors = append(c.processors, p)
+
+	p.cl = cl
 	go p.run(cl)
 
 	return nil
 }
 
+// Delivered returns, per partition, the offset following the last record
+// delivered for processing.
+func (c *Consumer) Delivered() map[int32]int64 {
+	c.audit.mu.Lock()
+	defer c.audit.mu.Unlock()
+
+	delivered := make(map[int32]int64, len(c.audit.delivered))
+	for p, o := range c.audit.delivered {
+		delivered[p] = o
+	}
+
+	return delivered
+}
+
+// Commits returns the number of commits made and the ones that failed.
+func (c *Consumer) Commits() (int, []CommitAudit) {
+	c.audit.mu.Lock()
+	defer c.audit.mu.Unlock()
+
+	return c.audit.commits, append([]CommitAudit{}, c.audit.failed...)
+}
+
+// BrokerStats returns what the consumer client observed of every broker.
+func (c *Consumer) BrokerStats() []telemetry.BrokerStats {
+	return c.telemetry.Snapshot()
+}
+
 func (c *Consumer) Shutdown() {
 	c.logger.Info("closing consumer")
 	for _, p := range c.processors {
 		p.Shutdown()
 	}
+
+	if c.audit != nil {
+		c.audit.close()
+	}
 }
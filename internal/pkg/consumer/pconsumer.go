@@ -5,6 +5,9 @@ import (
 	"sync"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/plugin/kotel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type pconsumer struct {
@@ -16,14 +19,16 @@ type pconsumer struct {
 	done chan struct{}
 	recs chan []*kgo.Record
 
-	res chan [][]byte
+	res chan []Record
 
+	audit  *audit
+	tracer *kotel.Tracer
 	logger Logger
 
 	consuming bool
 }
 
-func newPConsumer(cl *kgo.Client, topic string, partition int32, l Logger) *pconsumer {
+func newPConsumer(cl *kgo.Client, topic string, partition int32, a *audit, t *kotel.Tracer, l Logger) *pconsumer {
 	return &pconsumer{
 		cl:        cl,
 		topic:     topic,
@@ -33,8 +38,10 @@ func newPConsumer(cl *kgo.Client, topic string, partition int32, l Logger) *pcon
 		done: make(chan struct{}),
 		recs: make(chan []*kgo.Record, 5),
 
-		res: make(chan [][]byte),
+		res: make(chan []Record),
 
+		audit:  a,
+		tracer: t,
 		logger: l,
 
 		consuming: false,
@@ -43,6 +50,8 @@ func newPConsumer(cl *kgo.Client, topic string, partition int32, l Logger) *pcon
 
 func (pc *pconsumer) consume(wg *sync.WaitGroup) {
 	defer close(pc.done)
+	// ends the processor reading the records, once nothing is sent anymore
+	defer close(pc.res)
 
 	pc.logger.Infof("starting partition-consumer %d", pc.partition)
 	defer pc.logger.Infof("closing partition-consumer %d", pc.partition)
@@ -57,22 +66,63 @@ func (pc *pconsumer) consume(wg *sync.WaitGroup) {
 		case <-pc.quit:
 			return
 		case recs := <-pc.recs:
-			parsed := [][]byte{}
+			parsed := make([]Record, 0, len(recs))
+			spans := pc.startProcessSpans(recs)
 
 			for _, record := range recs {
-				parsed = append(parsed, record.Value)
+				parsed = append(parsed, Record{
+					Partition: record.Partition,
+					Offset:    record.Offset,
+					Timestamp: record.Timestamp,
+					Key:       record.Key,
+					Value:     record.Value,
+					Headers:   headers(record.Headers),
+				})
 			}
 
 			pc.res <- parsed
 
+			next := recs[len(recs)-1].Offset + 1
+			pc.audit.recordDelivered(pc.partition, next)
+
 			err := pc.cl.CommitRecords(context.Background(), recs...)
+			pc.audit.recordCommit(pc.partition, next, err)
+			endSpans(spans, err)
 			if err != nil {
-				pc.logger.Errorf("committing offsets with err: %v t: %s p: %d offset %d\n", err, pc.topic, pc.partition, recs[len(recs)-1].Offset+1)
+				pc.logger.RecordCommitError()
+				pc.logger.Errorf("committing offsets with err: %v t: %s p: %d offset %d\n", err, pc.topic, pc.partition, next)
 			}
 		}
 	}
 }
 
+// startProcessSpans starts a process span per record, child of the span
+// extracted from its traceparent header. They cover the hand-over to the
+// processor and the commit.
+func (pc *pconsumer) startProcessSpans(recs []*kgo.Record) []trace.Span {
+	if pc.tracer == nil {
+		return nil
+	}
+
+	spans := make([]trace.Span, 0, len(recs))
+	for _, record := range recs {
+		_, span := pc.tracer.WithProcessSpan(record)
+		spans = append(spans, span)
+	}
+
+	return spans
+}
+
+func endSpans(spans []trace.Span, err error) {
+	for _, span := range spans {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+		}
+		span.End()
+	}
+}
+
 func (pc *pconsumer) shutdown() {
 	pc.logger.Infof("stopping partition-consumer for t: %s p: %d\n", pc.topic, pc.partition)
 
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/plugin/kotel"
 )
 
 type tp struct {
@@ -14,17 +15,22 @@ type tp struct {
 }
 
 type processor struct {
-	callback  func(chan [][]byte)
+	callback  func(chan []Record)
 	consumers map[tp]*pconsumer
+	audit     *audit
+	tracer    *kotel.Tracer
 	logger    Logger
 	enabled   bool
 	wg        *sync.WaitGroup
+	cl        *kgo.Client
 }
 
-func newProcessor(callback func(chan [][]byte), l Logger) *processor {
+func newProcessor(callback func(chan []Record), a *audit, t *kotel.Tracer, l Logger) *processor {
 	return &processor{
 		callback:  callback,
 		consumers: make(map[tp]*pconsumer),
+		audit:     a,
+		tracer:    t,
 		logger:    l,
 		enabled:   true,
 		wg:        &sync.WaitGroup{},
@@ -54,7 +60,7 @@ func (p *processor) assigned(_ context.Context, cl *kgo.Client, assigned map[str
 
 			p.logger.AddedPartition()
 
-			pc := newPConsumer(cl, topic, partition, p.logger)
+			pc := newPConsumer(cl, topic, partition, p.audit, p.tracer, p.logger)
 
 			p.consumers[tp{topic, partition}] = pc
 
@@ -102,4 +108,10 @@ func (p *processor) Shutdown() {
 	}
 
 	p.wg.Wait()
+
+	// leaving the group right away rather than once the session times out,
+	// so that the next run with the same group gets the partitions
+	if p.cl != nil {
+		p.cl.Close()
+	}
 }
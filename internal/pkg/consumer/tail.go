@@ -0,0 +1,228 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"kafka-producer-consumer-tester/internal/pkg/auth"
+)
+
+// Header is a record header.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+func headers(hs []kgo.RecordHeader) []Header {
+	if len(hs) == 0 {
+		return nil
+	}
+
+	out := make([]Header, 0, len(hs))
+	for _, h := range hs {
+		out = append(out, Header{Key: h.Key, Value: h.Value})
+	}
+
+	return out
+}
+
+// Record is a record read by Tail or handed over to a processor.
+type Record struct {
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+}
+
+type TailConfig struct {
+	Seeds      []string
+	Topic      string
+	Auth       auth.AuthConfig
+	Partitions []int32 // all partitions when empty
+	From       string  // start, end, an offset number or an RFC3339 timestamp
+	Follow     bool    // keep reading new records instead of stopping at the end offsets
+}
+
+// tailIdle is how long a tail that doesn't follow waits for records before
+// deciding that nothing readable is left below the end offsets. Brokers answer
+// right away while there is, so only a tail compacted away or made of control
+// records, e.g. transaction markers, is waited for that long.
+const tailIdle = 10 * time.Second
+
+// Tailer reads a topic directly, without a consumer group.
+type Tailer struct {
+	cfg    TailConfig
+	logger Logger
+}
+
+func NewTailer(cfg TailConfig, l Logger) *Tailer {
+	return &Tailer{cfg: cfg, logger: l}
+}
+
+// Tail calls fn for every record read. Unless following, it stops once the end
+// offsets listed when it started are reached, or once nothing readable is left
+// before them.
+func (t *Tailer) Tail(ctx context.Context, fn func(Record) error) error {
+	cfg, l := t.cfg, t.logger
+
+	opts, err := auth.Opts(cfg.Auth)
+	if err != nil {
+		l.Errorf("configuring tail authentication: %v", err)
+		return err
+	}
+
+	// control records are kept, only to tell when a partition ending with a
+	// transaction marker is done
+	cl, err := kgo.NewClient(append(opts, kgo.SeedBrokers(cfg.Seeds...), kgo.KeepControlRecords())...)
+	if err != nil {
+		l.Errorf("creating tail client: %v", err)
+		return err
+	}
+	defer cl.Close()
+
+	starts, ends, err := tailRange(ctx, kadm.NewClient(cl), cfg)
+	if err != nil {
+		l.Errorf("resolving tail offsets: %v", err)
+		return err
+	}
+
+	offsets := map[int32]kgo.Offset{}
+	pending := map[int32]int64{}
+	for p, start := range starts {
+		offsets[p] = kgo.NewOffset().At(start)
+		if start < ends[p] {
+			pending[p] = ends[p]
+		}
+	}
+
+	if !cfg.Follow && len(pending) == 0 {
+		return nil
+	}
+
+	cl.AddConsumePartitions(map[string]map[int32]kgo.Offset{cfg.Topic: offsets})
+
+	for {
+		pctx, cancel := ctx, context.CancelFunc(func() {})
+		if !cfg.Follow {
+			pctx, cancel = context.WithTimeout(ctx, tailIdle)
+		}
+		fetches := cl.PollFetches(pctx)
+		idle := errors.Is(pctx.Err(), context.DeadlineExceeded) && fetches.NumRecords() == 0
+		cancel()
+
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+		if idle {
+			for p, end := range pending {
+				l.Infof("no readable records left in partition %d before offset %d", p, end)
+			}
+			return nil
+		}
+		fetches.EachError(func(t string, p int32, err error) {
+			l.Errorf("fetching t: %s p: %d: %v", t, p, err)
+		})
+
+		var ferr error
+		fetches.EachRecord(func(r *kgo.Record) {
+			if ferr != nil {
+				return
+			}
+
+			if !r.Attrs.IsControl() {
+				ferr = fn(Record{Partition: r.Partition, Offset: r.Offset, Timestamp: r.Timestamp, Key: r.Key, Value: r.Value, Headers: headers(r.Headers)})
+			}
+
+			if end, ok := pending[r.Partition]; ok && r.Offset+1 >= end {
+				delete(pending, r.Partition)
+			}
+		})
+		if ferr != nil {
+			return ferr
+		}
+
+		if !cfg.Follow && len(pending) == 0 {
+			return nil
+		}
+	}
+}
+
+// tailRange resolves, for every tailed partition, the offset to start from and
+// the end offset at the time of the call.
+func tailRange(ctx context.Context, adm *kadm.Client, cfg TailConfig) (map[int32]int64, map[int32]int64, error) {
+	listedEnds, err := adm.ListEndOffsets(ctx, cfg.Topic)
+	if err != nil {
+		return nil, nil, err
+	}
+	ends, err := offsetsOf(listedEnds, cfg.Topic, cfg.Partitions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var listed kadm.ListedOffsets
+
+	switch from := strings.TrimSpace(cfg.From); {
+	case from == "" || from == "start":
+		listed, err = adm.ListStartOffsets(ctx, cfg.Topic)
+	case from == "end":
+		return ends, ends, nil
+	default:
+		if offset, perr := strconv.ParseInt(from, 10, 64); perr == nil {
+			starts := map[int32]int64{}
+			for p := range ends {
+				starts[p] = offset
+			}
+			return starts, ends, nil
+		}
+
+		at, perr := time.Parse(time.RFC3339, from)
+		if perr != nil {
+			return nil, nil, fmt.Errorf("invalid tail start %q, expected start, end, an offset or an RFC3339 timestamp", from)
+		}
+		listed, err = adm.ListOffsetsAfterMilli(ctx, at.UnixMilli(), cfg.Topic)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	starts, err := offsetsOf(listed, cfg.Topic, cfg.Partitions)
+	return starts, ends, err
+}
+
+func offsetsOf(listed kadm.ListedOffsets, topic string, partitions []int32) (map[int32]int64, error) {
+	if err := listed.Error(); err != nil {
+		return nil, err
+	}
+
+	offsets := map[int32]int64{}
+	for p, o := range listed[topic] {
+		offsets[p] = o.Offset
+	}
+	if len(offsets) == 0 {
+		return nil, fmt.Errorf("topic %s not found", topic)
+	}
+
+	if len(partitions) == 0 {
+		return offsets, nil
+	}
+
+	selected := map[int32]int64{}
+	for _, p := range partitions {
+		o, ok := offsets[p]
+		if !ok {
+			return nil, fmt.Errorf("partition %d not found in topic %s", p, topic)
+		}
+		selected[p] = o
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,93 @@
+package load
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+const (
+	paceSlice = 100 * time.Millisecond // step the shape is followed with when charging a batch
+	minFactor = 0.01                   // keeps a shape at zero from stalling the load
+)
+
+// Pacer spaces batches out so that the produced records and bytes per second
+// follow the shape, scaled to the peak rates. A zero peak rate leaves the
+// corresponding dimension unlimited.
+type Pacer struct {
+	shape    Shape
+	rate     float64 // peak records per second
+	byteRate float64 // peak bytes per second
+
+	start time.Time
+	next  time.Time
+}
+
+func NewPacer(shape Shape, rate, byteRate float64) *Pacer {
+	now := time.Now()
+	return &Pacer{shape: shape, rate: rate, byteRate: byteRate, start: now, next: now}
+}
+
+// Limited reports whether the pacer throttles at all.
+func (p *Pacer) Limited() bool {
+	return p.rate > 0 || p.byteRate > 0
+}
+
+// Elapsed returns the time since the pacer was created.
+func (p *Pacer) Elapsed() time.Duration {
+	return time.Since(p.start)
+}
+
+// Wait blocks until the next batch can be sent without exceeding the target
+// rates, given what the batches before it were charged.
+func (p *Pacer) Wait(ctx context.Context) error {
+	if !p.Limited() {
+		return nil
+	}
+
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+
+	if wait := time.Until(p.next); wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	return nil
+}
+
+// Charge pushes the time the next batch can be sent back by what sending a
+// batch of the given size takes at the target rates. The shape is followed
+// slice by slice from the time the batch was sent, so a batch sent while the
+// shape is low is charged at the rates it climbs to rather than the ones of
+// its start.
+func (p *Pacer) Charge(records, bytes int) {
+	var demand float64 // seconds the batch takes at the peak rates
+	if p.rate > 0 {
+		demand = float64(records) / p.rate
+	}
+	if p.byteRate > 0 {
+		demand = math.Max(demand, float64(bytes)/p.byteRate)
+	}
+
+	for demand > 0 {
+		factor := math.Max(p.shape(p.next.Sub(p.start)), minFactor)
+
+		if step := paceSlice.Seconds() * factor; step < demand {
+			demand -= step
+			p.next = p.next.Add(paceSlice)
+			continue
+		}
+
+		p.next = p.next.Add(time.Duration(demand / factor * float64(time.Second)))
+		return
+	}
+}
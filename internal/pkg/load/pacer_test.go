@@ -0,0 +1,71 @@
+package load
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPacerCharge(t *testing.T) {
+	tests := []struct {
+		name           string
+		shape          ShapeConfig
+		rate, byteRate float64
+		records, bytes int
+		want           time.Duration // until the next batch, from the start
+	}{
+		{
+			name:  "constant rate",
+			shape: ShapeConfig{Name: Constant}, rate: 1000,
+			records: 500,
+			want:    500 * time.Millisecond,
+		},
+		{
+			name:  "byte rate of the first batch",
+			shape: ShapeConfig{Name: Constant}, byteRate: 1000,
+			records: 1, bytes: 2000,
+			want: 2 * time.Second,
+		},
+		{
+			name:  "the slower of both rates",
+			shape: ShapeConfig{Name: Constant}, rate: 1000, byteRate: 1000,
+			records: 1000, bytes: 3000,
+			want: 3 * time.Second,
+		},
+		{
+			// the ramp climbs from 0 at 100 records/s², 1000 records are sent
+			// once 50t² = 1000
+			name:  "zero base ramp at its start",
+			shape: ShapeConfig{Name: Ramp, Period: 10 * time.Second}, rate: 1000,
+			records: 1000,
+			want:    time.Duration(math.Sqrt(20) * float64(time.Second)),
+		},
+		{
+			// the first step stays at 0, the floor keeping the load going
+			name:  "zero base step at its start",
+			shape: ShapeConfig{Name: Step, Period: 10 * time.Second, Steps: 2}, rate: 1000,
+			records: 1000,
+			want:    10900 * time.Millisecond, // 10s at the 1% floor charge a tenth of it
+		},
+		{
+			name:    "unlimited",
+			shape:   ShapeConfig{Name: Constant},
+			records: 1000, bytes: 1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, err := NewShape(tt.shape)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := NewPacer(shape, tt.rate, tt.byteRate)
+			p.Charge(tt.records, tt.bytes)
+
+			if got := p.next.Sub(p.start); math.Abs(float64(got-tt.want)) > float64(paceSlice) {
+				t.Errorf("next batch after %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
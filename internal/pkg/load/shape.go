@@ -0,0 +1,73 @@
+package load
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	Constant = "constant"
+	Ramp     = "ramp"
+	Step     = "step"
+	Spike    = "spike"
+	Sine     = "sine"
+)
+
+// Shape returns, for the time elapsed since the load started, the fraction of
+// the peak rate that should be produced.
+type Shape func(elapsed time.Duration) float64
+
+type ShapeConfig struct {
+	Name          string
+	Base          float64       // fraction of the peak rate the shape starts from or falls back to
+	Period        time.Duration // ramp length, step length, spike interval or sine period
+	Steps         int           // number of steps of the step shape
+	SpikeDuration time.Duration // how long each spike lasts
+}
+
+func NewShape(cfg ShapeConfig) (Shape, error) {
+	base := math.Min(math.Max(cfg.Base, 0), 1)
+	period := cfg.Period.Seconds()
+
+	if cfg.Name != Constant && cfg.Name != "" && period <= 0 {
+		return nil, fmt.Errorf("load shape %s requires a positive period", cfg.Name)
+	}
+
+	switch cfg.Name {
+	case Constant, "":
+		return func(time.Duration) float64 { return 1 }, nil
+
+	case Ramp:
+		return func(elapsed time.Duration) float64 {
+			progress := math.Min(elapsed.Seconds()/period, 1)
+			return base + (1-base)*progress
+		}, nil
+
+	case Step:
+		if cfg.Steps < 2 {
+			return nil, fmt.Errorf("load shape %s requires at least 2 steps", cfg.Name)
+		}
+		return func(elapsed time.Duration) float64 {
+			step := math.Min(math.Floor(elapsed.Seconds()/period), float64(cfg.Steps-1))
+			return base + (1-base)*step/float64(cfg.Steps-1)
+		}, nil
+
+	case Spike:
+		spike := cfg.SpikeDuration.Seconds()
+		return func(elapsed time.Duration) float64 {
+			if math.Mod(elapsed.Seconds(), period) >= period-spike {
+				return 1
+			}
+			return base
+		}, nil
+
+	case Sine:
+		return func(elapsed time.Duration) float64 {
+			phase := 2 * math.Pi * elapsed.Seconds() / period
+			return base + (1-base)*(1-math.Cos(phase))/2
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown load shape %q", cfg.Name)
+}
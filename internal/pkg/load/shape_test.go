@@ -0,0 +1,71 @@
+package load
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestShape(t *testing.T) {
+	type point struct {
+		elapsed time.Duration
+		want    float64
+	}
+
+	tests := []struct {
+		cfg    ShapeConfig
+		points []point
+	}{
+		{ShapeConfig{Name: Constant}, []point{{0, 1}, {time.Hour, 1}}},
+		{ShapeConfig{}, []point{{0, 1}}},
+		{
+			ShapeConfig{Name: Ramp, Base: 0.5, Period: 10 * time.Second},
+			[]point{{0, 0.5}, {5 * time.Second, 0.75}, {10 * time.Second, 1}, {time.Minute, 1}},
+		},
+		{
+			ShapeConfig{Name: Ramp, Base: 2, Period: 10 * time.Second}, // the base is clamped to the peak
+			[]point{{0, 1}},
+		},
+		{
+			ShapeConfig{Name: Step, Base: 0.4, Period: 10 * time.Second, Steps: 3},
+			[]point{{0, 0.4}, {9 * time.Second, 0.4}, {10 * time.Second, 0.7}, {25 * time.Second, 1}, {time.Hour, 1}},
+		},
+		{
+			ShapeConfig{Name: Spike, Base: 0.2, Period: 10 * time.Second, SpikeDuration: 2 * time.Second},
+			[]point{{0, 0.2}, {7 * time.Second, 0.2}, {8 * time.Second, 1}, {9 * time.Second, 1}, {10 * time.Second, 0.2}, {18 * time.Second, 1}},
+		},
+		{
+			ShapeConfig{Name: Sine, Period: 10 * time.Second},
+			[]point{{0, 0}, {2500 * time.Millisecond, 0.5}, {5 * time.Second, 1}, {10 * time.Second, 0}},
+		},
+		{
+			ShapeConfig{Name: Sine, Base: 0.5, Period: 10 * time.Second},
+			[]point{{0, 0.5}, {5 * time.Second, 1}},
+		},
+	}
+	for _, tt := range tests {
+		shape, err := NewShape(tt.cfg)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.cfg, err)
+		}
+
+		for _, p := range tt.points {
+			if got := shape(p.elapsed); math.Abs(got-p.want) > 1e-9 {
+				t.Errorf("%+v after %s: got %v, want %v", tt.cfg, p.elapsed, got, p.want)
+			}
+		}
+	}
+}
+
+func TestShapeErrors(t *testing.T) {
+	for _, cfg := range []ShapeConfig{
+		{Name: Ramp},
+		{Name: Sine, Period: -time.Second},
+		{Name: Step, Period: time.Second, Steps: 1},
+		{Name: "square", Period: time.Second},
+	} {
+		if _, err := NewShape(cfg); err == nil {
+			t.Errorf("%+v: got no error", cfg)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package load
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const (
+	NoPadding = "none"
+	Fixed     = "fixed"
+	Uniform   = "uniform"
+	Normal    = "normal"
+	Weighted  = "weighted"
+)
+
+// Size returns the size in bytes the next payload should be padded to, 0 to
+// leave it as is.
+type Size func() int
+
+type SizeConfig struct {
+	Distribution string
+	Size         int    // fixed size, or mean of the normal distribution
+	Min          int    // smallest size of the uniform and normal distributions
+	Max          int    // largest size of the uniform and normal distributions, unbounded when 0 for the latter
+	StdDev       int    // standard deviation of the normal distribution
+	Weights      string // comma separated size:weight pairs of the weighted distribution
+}
+
+func NewSize(cfg SizeConfig) (Size, error) {
+	switch cfg.Distribution {
+	case NoPadding, "":
+		return func() int { return 0 }, nil
+
+	case Fixed:
+		if cfg.Size <= 0 {
+			return nil, fmt.Errorf("payload size distribution %s requires a positive size", cfg.Distribution)
+		}
+		return func() int { return cfg.Size }, nil
+
+	case Uniform:
+		if cfg.Min < 0 || cfg.Max < cfg.Min {
+			return nil, fmt.Errorf("payload size distribution %s requires 0 <= min <= max", cfg.Distribution)
+		}
+		return func() int { return cfg.Min + rand.Intn(cfg.Max-cfg.Min+1) }, nil
+
+	case Normal:
+		if cfg.Size <= 0 || cfg.StdDev < 0 {
+			return nil, fmt.Errorf("payload size distribution %s requires a positive mean and a non-negative standard deviation", cfg.Distribution)
+		}
+		max := float64(cfg.Max)
+		if cfg.Max <= 0 {
+			max = math.Inf(1)
+		}
+		return func() int {
+			size := rand.NormFloat64()*float64(cfg.StdDev) + float64(cfg.Size)
+			return int(math.Min(math.Max(size, float64(cfg.Min)), max))
+		}, nil
+
+	case Weighted:
+		sizes, cumulative, err := parseWeights(cfg.Weights)
+		if err != nil {
+			return nil, err
+		}
+		total := cumulative[len(cumulative)-1]
+		return func() int {
+			pick := rand.Float64() * total
+			for i, c := range cumulative {
+				if pick < c {
+					return sizes[i]
+				}
+			}
+			return sizes[len(sizes)-1]
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown payload size distribution %q", cfg.Distribution)
+}
+
+// parseWeights parses size:weight pairs, e.g. 100:80,10000:15,2000000:5,
+// returning the sizes and the cumulative weights.
+func parseWeights(s string) ([]int, []float64, error) {
+	var sizes []int
+	var cumulative []float64
+	var total float64
+
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		size, weight, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid payload size weight %q, expected size:weight", pair)
+		}
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			return nil, nil, fmt.Errorf("invalid payload size %q", size)
+		}
+		w, err := strconv.ParseFloat(weight, 64)
+		if err != nil || w <= 0 {
+			return nil, nil, fmt.Errorf("invalid payload size weight %q", weight)
+		}
+
+		total += w
+		sizes = append(sizes, n)
+		cumulative = append(cumulative, total)
+	}
+
+	if len(sizes) == 0 {
+		return nil, nil, fmt.Errorf("payload size distribution %s requires weights", Weighted)
+	}
+
+	return sizes, cumulative, nil
+}
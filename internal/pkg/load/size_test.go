@@ -0,0 +1,81 @@
+package load
+
+import (
+	"math"
+	"testing"
+)
+
+const draws = 10_000
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		cfg      SizeConfig
+		min, max int
+		mean     float64 // of the draws, within 5%, unchecked when 0
+	}{
+		{SizeConfig{Distribution: NoPadding}, 0, 0, 0},
+		{SizeConfig{}, 0, 0, 0},
+		{SizeConfig{Distribution: Fixed, Size: 512}, 512, 512, 512},
+		{SizeConfig{Distribution: Uniform, Min: 100, Max: 300}, 100, 300, 200},
+		{SizeConfig{Distribution: Uniform, Min: 7, Max: 7}, 7, 7, 7},
+		{SizeConfig{Distribution: Normal, Size: 1000, StdDev: 100}, 0, math.MaxInt, 1000},
+		{SizeConfig{Distribution: Normal, Size: 1000, StdDev: 1000, Min: 900, Max: 1100}, 900, 1100, 0},
+		{SizeConfig{Distribution: Weighted, Weights: "100:3, 1000:1"}, 100, 1000, 325},
+		{SizeConfig{Distribution: Weighted, Weights: "64:1"}, 64, 64, 64},
+	}
+	for _, tt := range tests {
+		size, err := NewSize(tt.cfg)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.cfg, err)
+		}
+
+		var sum float64
+		for range draws {
+			n := size()
+			if n < tt.min || n > tt.max {
+				t.Fatalf("%+v: drew %d, want it in [%d, %d]", tt.cfg, n, tt.min, tt.max)
+			}
+			sum += float64(n)
+		}
+
+		if mean := sum / draws; tt.mean != 0 && math.Abs(mean-tt.mean) > tt.mean*0.05 {
+			t.Errorf("%+v: mean of %v, want %v", tt.cfg, mean, tt.mean)
+		}
+	}
+}
+
+func TestWeightedOnlyDrawsListedSizes(t *testing.T) {
+	size, err := NewSize(SizeConfig{Distribution: Weighted, Weights: "100:80,10000:15,2000000:5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[int]int{}
+	for range draws {
+		seen[size()]++
+	}
+
+	if len(seen) != 3 || seen[100] < seen[10000] || seen[10000] < seen[2000000] {
+		t.Errorf("drew %v", seen)
+	}
+}
+
+func TestSizeErrors(t *testing.T) {
+	for _, cfg := range []SizeConfig{
+		{Distribution: Fixed},
+		{Distribution: Uniform, Min: -1, Max: 10},
+		{Distribution: Uniform, Min: 10, Max: 5},
+		{Distribution: Normal, StdDev: 10},
+		{Distribution: Normal, Size: 10, StdDev: -1},
+		{Distribution: Weighted},
+		{Distribution: Weighted, Weights: "100"},
+		{Distribution: Weighted, Weights: "x:1"},
+		{Distribution: Weighted, Weights: "-1:1"},
+		{Distribution: Weighted, Weights: "100:0"},
+		{Distribution: "pareto"},
+	} {
+		if _, err := NewSize(cfg); err == nil {
+			t.Errorf("%+v: got no error", cfg)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/pkg/telemetry"
+)
+
+// Console is a plain Logger writing to stderr, for runs without an
+// interactive terminal or whose stdout carries data.
+type Console struct {
+	log  *log.Logger
+	quit chan struct{}
+}
+
+func NewConsole() *Console {
+	return &Console{log: log.New(os.Stderr, "", log.LstdFlags), quit: make(chan struct{})}
+}
+
+func (c *Console) RecordSent(string)                     {}
+func (c *Console) RecordProcessed(string)                {}
+func (c *Console) RecordProduceError()                   {}
+func (c *Console) RecordCommitError()                    {}
+func (c *Console) RecordLatency(time.Duration)           {}
+func (c *Console) RecordLag(map[int32]int64, int64)      {}
+func (c *Console) RecordBrokers([]telemetry.BrokerStats) {}
+func (c *Console) RecordDistribution(map[int32]int64)    {}
+func (c *Console) RecordProbeLost()                      {}
+func (c *Console) RecordAvailability(float64)            {}
+func (c *Console) AddedPartition()                       {}
+func (c *Console) RemovedPartition()                     {}
+func (c *Console) AddedProcessor()                       {}
+func (c *Console) RemovedProcessor()                     {}
+
+func (c *Console) Info(msg string) {
+	c.log.Printf("INFO: %s", msg)
+}
+
+func (c *Console) Infof(format string, v ...any) {
+	c.log.Printf("INFO: "+format, v...)
+}
+
+func (c *Console) Error(msg string) {
+	c.log.Printf("ERROR: %s", msg)
+}
+
+func (c *Console) Errorf(format string, v ...any) {
+	c.log.Printf("ERROR: "+format, v...)
+}
+
+// Done is never closed, the console has no way to ask to quit; signals are
+// used instead.
+func (c *Console) Done() <-chan struct{} {
+	return c.quit
+}
+
+func (c *Console) Shutdown() {}
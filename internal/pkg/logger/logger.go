@@ -2,12 +2,15 @@ package logger
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
+
+	"kafka-producer-consumer-tester/internal/pkg/telemetry"
 )
 
 type MessageStats struct {
@@ -28,7 +31,18 @@ type Logger struct {
 	partitions int
 	processors int
 
+	lagTable *widgets.Table
+	lag      map[int32]int64
+	totalLag int64
+	produced map[int32]int64
+
+	brokersTable *widgets.Table
+	brokers      []telemetry.BrokerStats
+
 	logsList *widgets.List
+
+	quit     chan struct{}
+	shutdown sync.Once
 }
 
 func New() (*Logger, error) {
@@ -38,7 +52,7 @@ func New() (*Logger, error) {
 
 	grid := ui.NewGrid()
 	termWidth, _ := ui.TerminalDimensions()
-	grid.SetRect(0, 0, termWidth, 30)
+	grid.SetRect(0, 0, termWidth, 52)
 
 	// Message verification table
 	msgsTable := widgets.NewTable()
@@ -66,6 +80,31 @@ func New() (*Logger, error) {
 	partTable.RowSeparator = true
 	partTable.BorderStyle = ui.NewStyle(ui.ColorCyan)
 
+	// Consumer lag and produced records table, one column per partition
+	lagTable := widgets.NewTable()
+	lagTable.Title = "Partitions"
+	lagTable.Rows = [][]string{
+		{"Partition", "Total", "Skew"},
+		{"Lag", "0", "-"},
+		{"Produced", "0", "-"},
+	}
+	lagTable.TextStyle = ui.NewStyle(ui.ColorWhite)
+	lagTable.TextAlignment = ui.AlignCenter
+	lagTable.RowSeparator = true
+	lagTable.BorderStyle = ui.NewStyle(ui.ColorCyan)
+
+	// Broker telemetry table, one row per client and broker
+	brokersTable := widgets.NewTable()
+	brokersTable.Title = "Broker Telemetry"
+	brokersTable.Rows = [][]string{
+		brokersHeader,
+		{"-", "-", "-", "-", "-", "-", "-"},
+		{"-", "-", "-", "-", "-", "-", "-"},
+	}
+	brokersTable.TextStyle = ui.NewStyle(ui.ColorWhite)
+	brokersTable.TextAlignment = ui.AlignCenter
+	brokersTable.BorderStyle = ui.NewStyle(ui.ColorCyan)
+
 	// General logs table
 	logsList := widgets.NewList()
 	logsList.Title = "Logs"
@@ -78,13 +117,17 @@ func New() (*Logger, error) {
 	// Calculate the required height for the message table
 	msgsHeight := len(msgsTable.Rows) + 1
 	partHeight := len(partTable.Rows) + 1
+	lagHeight := len(lagTable.Rows) + 1
+	brokersHeight := len(brokersTable.Rows) + 1
 	logsHeight := len(logsList.Rows) + 1
 
-	totalHeight := msgsHeight + partHeight + logsHeight
+	totalHeight := msgsHeight + partHeight + lagHeight + brokersHeight + logsHeight
 
 	grid.Set(
 		ui.NewRow(2.3/float64(totalHeight), ui.NewCol(1.0, msgsTable)),
 		ui.NewRow(2.3/float64(totalHeight), ui.NewCol(1.0, partTable)),
+		ui.NewRow(2.3/float64(totalHeight), ui.NewCol(1.0, lagTable)),
+		ui.NewRow(2.3/float64(totalHeight), ui.NewCol(1.0, brokersTable)),
 		ui.NewRow(6/float64(totalHeight), ui.NewCol(1.0, logsList)),
 	)
 	ui.Render(grid)
@@ -92,7 +135,7 @@ func New() (*Logger, error) {
 	// Refresh rate
 	ticker := time.NewTicker(time.Millisecond * 500)
 
-	logger := &Logger{msgsTable: msgsTable, ticker: ticker, logsList: logsList, partTable: partTable}
+	logger := &Logger{msgsTable: msgsTable, ticker: ticker, logsList: logsList, partTable: partTable, lagTable: lagTable, brokersTable: brokersTable, quit: make(chan struct{})}
 
 	go logger.run()
 
@@ -126,12 +169,89 @@ func (l *Logger) updatePartTable() {
 	ui.Render(l.partTable)
 }
 
+func (l *Logger) updateLagTable() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	seen := map[int32]bool{}
+	partitions := make([]int32, 0, len(l.lag))
+	for _, counts := range []map[int32]int64{l.lag, l.produced} {
+		for p := range counts {
+			if !seen[p] {
+				seen[p] = true
+				partitions = append(partitions, p)
+			}
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+	header := []string{"Partition"}
+	lag := []string{"Lag"}
+	produced := []string{"Produced"}
+	var total, max int64
+	for _, p := range partitions {
+		header = append(header, fmt.Sprintf("%d", p))
+		lag = append(lag, fmt.Sprintf("%d", l.lag[p]))
+		produced = append(produced, fmt.Sprintf("%d", l.produced[p]))
+
+		total += l.produced[p]
+		if l.produced[p] > max {
+			max = l.produced[p]
+		}
+	}
+
+	skew := "-"
+	if total > 0 {
+		skew = fmt.Sprintf("%.2f", float64(max)*float64(len(partitions))/float64(total))
+	}
+
+	header = append(header, "Total", "Skew")
+	lag = append(lag, fmt.Sprintf("%d", l.totalLag), "-")
+	produced = append(produced, fmt.Sprintf("%d", total), skew)
+
+	l.lagTable.Rows = [][]string{header, lag, produced}
+	ui.Render(l.lagTable)
+}
+
+var brokersHeader = []string{"Client/Broker", "Response Wait p99", "Write p99", "Throttled", "Batches", "Avg Batch", "Compression"}
+
+func (l *Logger) updateBrokersTable() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.brokers) == 0 {
+		return
+	}
+
+	rows := [][]string{brokersHeader}
+	for _, b := range l.brokers {
+		batches, avg, compression := b.ProducedBatches, b.AverageProducedBatch, b.ProduceCompression
+		if b.FetchedBatches > 0 {
+			batches, avg, compression = b.FetchedBatches, b.AverageFetchedBatch, b.FetchCompression
+		}
+
+		rows = append(rows, []string{
+			fmt.Sprintf("%s/%s", b.Client, b.Broker),
+			b.ResponseWait.P99.String(),
+			b.WriteLatency.P99.String(),
+			b.ThrottleTime.String(),
+			fmt.Sprintf("%d", batches),
+			fmt.Sprintf("%.1f", avg),
+			fmt.Sprintf("%.2fx", compression),
+		})
+	}
+
+	l.brokersTable.Rows = rows
+	ui.Render(l.brokersTable)
+}
+
 func (l *Logger) run() {
 	uiEvents := ui.PollEvents()
 	for {
 		select {
 		case e := <-uiEvents:
 			if e.ID == "q" || e.ID == "<C-c>" {
+				close(l.quit)
 				l.Shutdown()
 				return
 			}
@@ -139,6 +259,8 @@ func (l *Logger) run() {
 			l.updateMsgsTable()
 			l.updateLogList()
 			l.updatePartTable()
+			l.updateLagTable()
+			l.updateBrokersTable()
 		}
 	}
 }
@@ -169,6 +291,46 @@ func (l *Logger) RecordProcessed(status string) {
 	}
 }
 
+// RecordBrokers stores the latest per-broker telemetry of the clients.
+func (l *Logger) RecordBrokers(brokers []telemetry.BrokerStats) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.brokers = brokers
+}
+
+// RecordProduceError is a no-op, produce errors are already logged.
+func (l *Logger) RecordProduceError() {}
+
+// RecordCommitError is a no-op, commit errors are already logged.
+func (l *Logger) RecordCommitError() {}
+
+// RecordLatency is a no-op, latency is summarized in the report.
+func (l *Logger) RecordLatency(time.Duration) {}
+
+// RecordProbeLost is a no-op, lost probes are logged as errors.
+func (l *Logger) RecordProbeLost() {}
+
+// RecordAvailability is a no-op, the availability is logged with every canary report.
+func (l *Logger) RecordAvailability(float64) {}
+
+// RecordLag stores the latest consumer lag per partition and in total.
+func (l *Logger) RecordLag(partitions map[int32]int64, total int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.lag = partitions
+	l.totalLag = total
+}
+
+// RecordDistribution stores the records produced so far per partition.
+func (l *Logger) RecordDistribution(partitions map[int32]int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.produced = partitions
+}
+
 func (l *Logger) AddedPartition() {
 	l.partitions++
 }
@@ -201,7 +363,7 @@ func (l *Logger) Errorf(format string, v ...any) {
 	l.logsList.Rows = append(l.logsList.Rows, fmt.Sprintf("ERROR: %s", fmt.Sprintf(format, v...)))
 }
 
-func captureUIState(msgsTable *widgets.Table, partTable *widgets.Table, logsList *widgets.List) string {
+func captureUIState(msgsTable *widgets.Table, partTable *widgets.Table, lagTable *widgets.Table, brokersTable *widgets.Table, logsList *widgets.List) string {
 	var builder strings.Builder
 
 	// Function to capture table data
@@ -216,6 +378,8 @@ func captureUIState(msgsTable *widgets.Table, partTable *widgets.Table, logsList
 	// Capture each table
 	captureTable(msgsTable)
 	captureTable(partTable)
+	captureTable(lagTable)
+	captureTable(brokersTable)
 
 	// Capture list data
 	builder.WriteString(logsList.Title + "\n")
@@ -226,14 +390,21 @@ func captureUIState(msgsTable *widgets.Table, partTable *widgets.Table, logsList
 	return builder.String()
 }
 
+// Done is closed when the user asks to quit from the terminal.
+func (l *Logger) Done() <-chan struct{} {
+	return l.quit
+}
+
 func (l *Logger) Shutdown() {
-	l.Info("the app will be closed in 10 seconds...")
-	l.Info("Thank you!")
+	l.shutdown.Do(func() {
+		l.Info("the app will be closed in 10 seconds...")
+		l.Info("Thank you!")
 
-	time.Sleep(time.Second * 10)
-	l.ticker.Stop()
+		time.Sleep(time.Second * 10)
+		l.ticker.Stop()
 
-	ui.Close()
+		ui.Close()
+	})
 }
 
 func calculateProgress(total, current int) float64 {
@@ -0,0 +1,218 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	NDJSON = "ndjson"
+	Binary = "binary"
+)
+
+// magic starts binary manifests, telling them apart from NDJSON ones.
+var magic = []byte("KPCTM1\n")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Entry is a single event listed in a manifest, along with where and when it
+// was written to the topic.
+type Entry struct {
+	ID        string `json:"id"`
+	State     string `json:"state"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Timestamp int64  `json:"ts"`     // unix nanoseconds
+	Digest    uint32 `json:"digest"` // CRC-32C of the record value
+}
+
+// Digest returns the digest of a record value listed in manifest entries.
+func Digest(value []byte) uint32 {
+	return crc32.Checksum(value, castagnoli)
+}
+
+// Writer appends entries to a manifest file, either as JSON lines or in a
+// compact varint encoded binary format. It is safe for concurrent use.
+type Writer struct {
+	mu sync.Mutex
+
+	file *os.File
+	buf  *bufio.Writer
+	enc  *json.Encoder
+
+	binary  bool
+	scratch []byte
+
+	count int64
+}
+
+func Create(path, format string) (*Writer, error) {
+	if format != NDJSON && format != Binary && format != "" {
+		return nil, fmt.Errorf("unknown manifest format %q", format)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bufio.NewWriter(f)
+	w := &Writer{file: f, buf: buf, enc: json.NewEncoder(buf), binary: format == Binary}
+
+	if w.binary {
+		if _, err := buf.Write(magic); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func (w *Writer) Write(e Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.count++
+
+	if !w.binary {
+		return w.enc.Encode(e)
+	}
+
+	b := w.scratch[:0]
+	b = binary.AppendUvarint(b, uint64(len(e.ID)))
+	b = append(b, e.ID...)
+	b = binary.AppendUvarint(b, uint64(len(e.State)))
+	b = append(b, e.State...)
+	b = binary.AppendVarint(b, int64(e.Partition))
+	b = binary.AppendVarint(b, e.Offset)
+	b = binary.AppendVarint(b, e.Timestamp)
+	b = binary.BigEndian.AppendUint32(b, e.Digest)
+	w.scratch = b
+
+	_, err := w.buf.Write(b)
+	return err
+}
+
+// Count returns the number of entries written so far.
+func (w *Writer) Count() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.count
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+
+	return w.file.Close()
+}
+
+// Read calls fn for every entry of the manifest, in order. The format is
+// detected from the content.
+func Read(path string, fn func(Entry) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	head, err := r.Peek(len(magic))
+	if err == nil && bytes.Equal(head, magic) {
+		r.Discard(len(magic))
+		return readBinary(r, fn)
+	}
+
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		var e Entry
+		if err := dec.Decode(&e); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readBinary(r *bufio.Reader, fn func(Entry) error) error {
+	for {
+		var e Entry
+
+		id, err := readString(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e.ID = id
+
+		if e.State, err = readString(r); err != nil {
+			return unexpected(err)
+		}
+
+		partition, err := binary.ReadVarint(r)
+		if err != nil {
+			return unexpected(err)
+		}
+		e.Partition = int32(partition)
+
+		if e.Offset, err = binary.ReadVarint(r); err != nil {
+			return unexpected(err)
+		}
+		if e.Timestamp, err = binary.ReadVarint(r); err != nil {
+			return unexpected(err)
+		}
+
+		var digest [4]byte
+		if _, err := io.ReadFull(r, digest[:]); err != nil {
+			return unexpected(err)
+		}
+		e.Digest = binary.BigEndian.Uint32(digest[:])
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", unexpected(err)
+	}
+
+	return string(b), nil
+}
+
+// unexpected turns an EOF in the middle of an entry into a truncation error.
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package manifest
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var entries = []Entry{
+	{ID: "a", State: "success", Partition: 0, Offset: 0, Timestamp: 1_700_000_000_000_000_000, Digest: Digest([]byte("a"))},
+	{ID: "b", State: "failed", Partition: 11, Offset: 1 << 40, Timestamp: 1, Digest: 0xffffffff},
+	{ID: "", State: "", Partition: -1, Offset: -1, Timestamp: -1},
+}
+
+func write(t *testing.T, format string, entries []Entry) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "manifest")
+	w, err := Create(path, format)
+	if err != nil {
+		t.Fatalf("creating the manifest: %v", err)
+	}
+	for _, e := range entries {
+		if err := w.Write(e); err != nil {
+			t.Fatalf("writing %+v: %v", e, err)
+		}
+	}
+	if n := w.Count(); n != int64(len(entries)) {
+		t.Errorf("counted %d entries, want %d", n, len(entries))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing the manifest: %v", err)
+	}
+
+	return path
+}
+
+func read(path string) ([]Entry, error) {
+	var got []Entry
+	err := Read(path, func(e Entry) error {
+		got = append(got, e)
+		return nil
+	})
+	return got, err
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		format  string
+		entries []Entry
+	}{
+		{NDJSON, entries},
+		{Binary, entries},
+		{"", entries}, // NDJSON
+		{NDJSON, nil},
+		{Binary, nil},
+	}
+	for _, tt := range tests {
+		got, err := read(write(t, tt.format, tt.entries))
+		if err != nil {
+			t.Fatalf("%q: reading: %v", tt.format, err)
+		}
+		if !reflect.DeepEqual(got, tt.entries) {
+			t.Errorf("%q: got %+v, want %+v", tt.format, got, tt.entries)
+		}
+	}
+}
+
+func TestBinaryIsSmaller(t *testing.T) {
+	size := func(format string) int64 {
+		fi, err := os.Stat(write(t, format, entries))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Size()
+	}
+
+	if b, j := size(Binary), size(NDJSON); b >= j {
+		t.Errorf("binary manifest of %d bytes, NDJSON one of %d", b, j)
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	path := write(t, Binary, entries[:1])
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b[:len(b)-1], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := read(path); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("reading a truncated manifest: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestReadStops(t *testing.T) {
+	stop := errors.New("stop")
+
+	for _, format := range []string{NDJSON, Binary} {
+		n := 0
+		err := Read(write(t, format, entries), func(Entry) error {
+			n++
+			return stop
+		})
+		if !errors.Is(err, stop) || n != 1 {
+			t.Errorf("%s: got %v after %d entries, want %v after 1", format, err, n, stop)
+		}
+	}
+}
+
+func TestCreateUnknownFormat(t *testing.T) {
+	if _, err := Create(filepath.Join(t.TempDir(), "manifest"), "csv"); err == nil {
+		t.Error("creating a csv manifest: got no error")
+	}
+}
@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"kafka-producer-consumer-tester/internal/pkg/telemetry"
+)
+
+type Logger interface {
+	RecordSent(string)
+	RecordProcessed(string)
+	RecordProduceError()
+	RecordCommitError()
+	RecordLatency(time.Duration)
+	RecordLag(map[int32]int64, int64)
+	RecordDistribution(map[int32]int64)
+	RecordBrokers([]telemetry.BrokerStats)
+	RecordProbeLost()
+	RecordAvailability(float64)
+
+	Info(string)
+	Infof(string, ...any)
+	Error(string)
+	Errorf(string, ...any)
+
+	AddedPartition()
+	RemovedPartition()
+	AddedProcessor()
+	RemovedProcessor()
+}
+
+// Metrics decorates a Logger, mirroring everything it records into Prometheus
+// collectors served on /metrics.
+type Metrics struct {
+	Logger
+
+	registry *prometheus.Registry
+	server   *http.Server
+
+	sent          *prometheus.CounterVec
+	processed     *prometheus.CounterVec
+	produceErrors prometheus.Counter
+	commitErrors  prometheus.Counter
+	partitions    prometheus.Gauge
+	processors    prometheus.Gauge
+	lag           *prometheus.GaugeVec
+	totalLag      prometheus.Gauge
+	produced      *prometheus.GaugeVec
+	latency       prometheus.Histogram
+	probesLost    prometheus.Counter
+	availability  prometheus.Gauge
+}
+
+type MetricsConfig struct {
+	Addr string // listen address of the /metrics endpoint, not served when empty
+}
+
+func New(cfg MetricsConfig, l Logger) *Metrics {
+	m := &Metrics{
+		Logger:   l,
+		registry: prometheus.NewRegistry(),
+
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tester_records_sent_total",
+			Help: "Records acknowledged by the broker, by event state.",
+		}, []string{"state"}),
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tester_records_processed_total",
+			Help: "Records consumed and processed, by event state.",
+		}, []string{"state"}),
+		produceErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tester_produce_errors_total",
+			Help: "Failed produce requests.",
+		}),
+		commitErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tester_commit_errors_total",
+			Help: "Failed offset commits.",
+		}),
+		partitions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tester_active_partitions",
+			Help: "Partitions currently assigned to the consumer.",
+		}),
+		processors: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tester_active_processors",
+			Help: "Partition processors currently running.",
+		}),
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tester_consumer_lag",
+			Help: "Consumer group lag, by partition.",
+		}, []string{"partition"}),
+		totalLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tester_consumer_lag_total",
+			Help: "Consumer group lag summed over all partitions.",
+		}),
+		produced: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "tester_partition_records_produced",
+			Help: "Records acknowledged by the broker, by partition.",
+		}, []string{"partition"}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "tester_end_to_end_latency_seconds",
+			Help:    "Time from producing a record to processing it.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 18),
+		}),
+		probesLost: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tester_canary_probes_lost_total",
+			Help: "Canary probes not consumed within the canary timeout.",
+		}),
+		availability: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tester_canary_availability",
+			Help: "Share of canary probes successfully produced since the canary started.",
+		}),
+	}
+
+	m.registry.MustRegister(m.sent, m.processed, m.produceErrors, m.commitErrors, m.partitions, m.processors, m.lag, m.totalLag, m.produced, m.latency, m.probesLost, m.availability)
+
+	if cfg.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+
+		m.server = &http.Server{Addr: cfg.Addr, Handler: mux}
+
+		go func() {
+			l.Infof("serving metrics on %s/metrics", cfg.Addr)
+			if err := m.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				l.Errorf("serving metrics: %v", err)
+			}
+		}()
+	}
+
+	return m
+}
+
+func (m *Metrics) RecordSent(st string) {
+	m.Logger.RecordSent(st)
+	m.sent.WithLabelValues(st).Inc()
+}
+
+func (m *Metrics) RecordProcessed(st string) {
+	m.Logger.RecordProcessed(st)
+	m.processed.WithLabelValues(st).Inc()
+}
+
+func (m *Metrics) RecordProduceError() {
+	m.Logger.RecordProduceError()
+	m.produceErrors.Inc()
+}
+
+func (m *Metrics) RecordCommitError() {
+	m.Logger.RecordCommitError()
+	m.commitErrors.Inc()
+}
+
+func (m *Metrics) RecordLatency(d time.Duration) {
+	m.Logger.RecordLatency(d)
+	m.latency.Observe(d.Seconds())
+}
+
+func (m *Metrics) RecordLag(partitions map[int32]int64, total int64) {
+	m.Logger.RecordLag(partitions, total)
+
+	for p, lag := range partitions {
+		m.lag.WithLabelValues(strconv.Itoa(int(p))).Set(float64(lag))
+	}
+	m.totalLag.Set(float64(total))
+}
+
+func (m *Metrics) RecordDistribution(partitions map[int32]int64) {
+	m.Logger.RecordDistribution(partitions)
+
+	for p, n := range partitions {
+		m.produced.WithLabelValues(strconv.Itoa(int(p))).Set(float64(n))
+	}
+}
+
+func (m *Metrics) RecordProbeLost() {
+	m.Logger.RecordProbeLost()
+	m.probesLost.Inc()
+}
+
+func (m *Metrics) RecordAvailability(a float64) {
+	m.Logger.RecordAvailability(a)
+	m.availability.Set(a)
+}
+
+func (m *Metrics) AddedPartition() {
+	m.Logger.AddedPartition()
+	m.partitions.Inc()
+}
+
+func (m *Metrics) RemovedPartition() {
+	m.Logger.RemovedPartition()
+	m.partitions.Dec()
+}
+
+func (m *Metrics) AddedProcessor() {
+	m.Logger.AddedProcessor()
+	m.processors.Inc()
+}
+
+func (m *Metrics) RemovedProcessor() {
+	m.Logger.RemovedProcessor()
+	m.processors.Dec()
+}
+
+// Reset clears the per-partition gauges, so that a run doesn't report the
+// partitions of the previous one.
+func (m *Metrics) Reset() {
+	m.lag.Reset()
+	m.totalLag.Set(0)
+	m.produced.Reset()
+}
+
+func (m *Metrics) Shutdown() {
+	if m.server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	m.Info("closing metrics server")
+	_ = m.server.Shutdown(ctx)
+}
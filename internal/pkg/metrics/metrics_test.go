@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"kafka-producer-consumer-tester/internal/pkg/logger"
+)
+
+func TestProcessorsCountDown(t *testing.T) {
+	m := New(MetricsConfig{}, logger.NewConsole())
+
+	m.AddedPartition()
+	m.AddedProcessor()
+	m.AddedProcessor()
+	m.RemovedProcessor()
+	m.RemovedPartition()
+
+	if got := testutil.ToFloat64(m.partitions); got != 0 {
+		t.Errorf("got %v active partitions, want 0", got)
+	}
+	if got := testutil.ToFloat64(m.processors); got != 1 {
+		t.Errorf("got %v active processors, want 1", got)
+	}
+}
+
+func TestReset(t *testing.T) {
+	m := New(MetricsConfig{}, logger.NewConsole())
+
+	m.RecordLag(map[int32]int64{0: 5, 1: 7}, 12)
+	m.RecordDistribution(map[int32]int64{0: 10, 1: 20, 2: 30})
+
+	m.Reset()
+	if n := testutil.CollectAndCount(m.lag); n != 0 {
+		t.Errorf("got the lag of %d partitions after a reset, want none", n)
+	}
+	if n := testutil.CollectAndCount(m.produced); n != 0 {
+		t.Errorf("got the records produced to %d partitions after a reset, want none", n)
+	}
+	if got := testutil.ToFloat64(m.totalLag); got != 0 {
+		t.Errorf("got a total lag of %v after a reset, want 0", got)
+	}
+
+	// the next run only reports its own partitions
+	m.RecordDistribution(map[int32]int64{0: 1})
+	if n := testutil.CollectAndCount(m.produced); n != 1 {
+		t.Errorf("got the records produced to %d partitions, want 1", n)
+	}
+}
@@ -0,0 +1,37 @@
+package producer
+
+import (
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Acknowledgements a produced batch waits for.
+const (
+	AcksAll    = "all"
+	AcksLeader = "leader"
+	AcksNone   = "none"
+)
+
+// acks returns the options requiring the given acknowledgements. Idempotent
+// writes need all in-sync replicas to acknowledge, so they are disabled
+// otherwise.
+func acks(name string) ([]kgo.Opt, error) {
+	switch name {
+	case AcksAll, "":
+		return []kgo.Opt{kgo.RequiredAcks(kgo.AllISRAcks())}, nil
+	case AcksLeader:
+		return []kgo.Opt{kgo.RequiredAcks(kgo.LeaderAck()), kgo.DisableIdempotentWrite()}, nil
+	case AcksNone:
+		return []kgo.Opt{kgo.RequiredAcks(kgo.NoAck()), kgo.DisableIdempotentWrite()}, nil
+	default:
+		return nil, fmt.Errorf("unknown acks %q", name)
+	}
+}
+
+// ValidAcks returns an error if the producer doesn't know the
+// acknowledgements.
+func ValidAcks(name string) error {
+	_, err := acks(name)
+	return err
+}
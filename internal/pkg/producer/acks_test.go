@@ -0,0 +1,44 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestAcks(t *testing.T) {
+	tests := []struct {
+		name           string
+		want           kgo.Acks
+		idempotentless bool
+	}{
+		{"", kgo.AllISRAcks(), false},
+		{AcksAll, kgo.AllISRAcks(), false},
+		{AcksLeader, kgo.LeaderAck(), true},
+		{AcksNone, kgo.NoAck(), true},
+	}
+	for _, tt := range tests {
+		opts, err := acks(tt.name)
+		if err != nil {
+			t.Fatalf("acks %q: %v", tt.name, err)
+		}
+
+		// the client rejects acks other than all with idempotent writes
+		cl, err := kgo.NewClient(append(opts, kgo.SeedBrokers("127.0.0.1:1"))...)
+		if err != nil {
+			t.Fatalf("acks %q: %v", tt.name, err)
+		}
+		got, disabled := cl.OptValue(kgo.RequiredAcks), cl.OptValue(kgo.DisableIdempotentWrite)
+		cl.Close()
+
+		if got != tt.want || disabled != tt.idempotentless {
+			t.Errorf("acks %q: got %+v with idempotent writes disabled: %v, want %+v and %t", tt.name, got, disabled, tt.want, tt.idempotentless)
+		}
+	}
+
+	for _, name := range []string{"1", "-1", "ALL"} {
+		if err := ValidAcks(name); err == nil {
+			t.Errorf("acks %q: got no error", name)
+		}
+	}
+}
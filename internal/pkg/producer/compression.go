@@ -0,0 +1,63 @@
+package producer
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Compression codecs of the produced batches.
+const (
+	None   = "none"
+	Gzip   = "gzip"
+	Snappy = "snappy"
+	LZ4    = "lz4"
+	Zstd   = "zstd"
+)
+
+// levels are the ones the codecs accept, from compress/gzip's HuffmanOnly to
+// BestCompression and from zstd's SpeedFastest to SpeedBestCompression. kgo
+// falls back to the default level when given another one, so a run would
+// silently use it. The lz4 levels don't fit into the level of kgo.
+var levels = map[string][2]int{Gzip: {-2, 9}, Zstd: {1, 4}}
+
+// compression returns the codec with the given name, at the given level
+// unless it is 0. Snappy has no levels.
+func compression(name string, level int) (kgo.CompressionCodec, error) {
+	var c kgo.CompressionCodec
+	switch name {
+	case None:
+		c = kgo.NoCompression()
+	case Snappy, "":
+		c = kgo.SnappyCompression()
+	case Gzip:
+		c = kgo.GzipCompression()
+	case LZ4:
+		c = kgo.Lz4Compression()
+	case Zstd:
+		c = kgo.ZstdCompression()
+	default:
+		return c, fmt.Errorf("unknown compression %q", name)
+	}
+
+	if level != 0 {
+		r, ok := levels[name]
+		if !ok {
+			return c, fmt.Errorf("no level can be set with %s compression", cmp.Or(name, Snappy))
+		}
+		if level < r[0] || level > r[1] {
+			return c, fmt.Errorf("%s compression level %d out of %d to %d", name, level, r[0], r[1])
+		}
+		c = c.WithLevel(level)
+	}
+
+	return c, nil
+}
+
+// ValidCompression returns an error if the producer doesn't know the
+// compression.
+func ValidCompression(name string, level int) error {
+	_, err := compression(name, level)
+	return err
+}
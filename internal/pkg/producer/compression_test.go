@@ -0,0 +1,53 @@
+package producer
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestCompression(t *testing.T) {
+	tests := []struct {
+		name  string
+		level int
+		want  kgo.CompressionCodec
+		ok    bool
+	}{
+		{"", 0, kgo.SnappyCompression(), true},
+		{None, 0, kgo.NoCompression(), true},
+		{Snappy, 0, kgo.SnappyCompression(), true},
+		{Gzip, 0, kgo.GzipCompression(), true},
+		{LZ4, 0, kgo.Lz4Compression(), true},
+		{Zstd, 0, kgo.ZstdCompression(), true},
+
+		{Gzip, 1, kgo.GzipCompression().WithLevel(1), true},
+		{Gzip, 9, kgo.GzipCompression().WithLevel(9), true},
+		{Gzip, -2, kgo.GzipCompression().WithLevel(-2), true}, // Huffman only
+		{Zstd, 1, kgo.ZstdCompression().WithLevel(1), true},
+		{Zstd, 4, kgo.ZstdCompression().WithLevel(4), true},
+
+		{Gzip, 10, kgo.CompressionCodec{}, false},
+		{Gzip, -3, kgo.CompressionCodec{}, false},
+		{Zstd, 5, kgo.CompressionCodec{}, false},
+		{Zstd, 22, kgo.CompressionCodec{}, false},
+		{Zstd, -1, kgo.CompressionCodec{}, false},
+		{LZ4, 9, kgo.CompressionCodec{}, false},
+		{Snappy, 1, kgo.CompressionCodec{}, false},
+		{"", 1, kgo.CompressionCodec{}, false},
+		{None, 1, kgo.CompressionCodec{}, false},
+		{"brotli", 0, kgo.CompressionCodec{}, false},
+	}
+	for _, tt := range tests {
+		got, err := compression(tt.name, tt.level)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q at level %d: got error %v", tt.name, tt.level, err)
+			continue
+		}
+		if tt.ok && got != tt.want {
+			t.Errorf("%q at level %d: got %+v, want %+v", tt.name, tt.level, got, tt.want)
+		}
+		if err := ValidCompression(tt.name, tt.level); (err == nil) != tt.ok {
+			t.Errorf("validating %q at level %d: got error %v", tt.name, tt.level, err)
+		}
+	}
+}
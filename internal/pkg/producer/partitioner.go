@@ -0,0 +1,52 @@
+package producer
+
+import (
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Partitioners picking the partition of the produced records.
+const (
+	Default     = "default"      // franz-go's, sticky by batch size and hashing keys with murmur2
+	Sticky      = "sticky"       // sticks to a partition per batch, ignoring keys
+	RoundRobin  = "round-robin"  // one partition after the other, ignoring keys
+	KeyHash     = "murmur2"      // murmur2 key hash like the Java client, sticky for records without key
+	LeastBackup = "least-backup" // the partition with the fewest buffered records, ignoring keys
+	Manual      = "manual"       // a single partition set in the config
+)
+
+func partitioner(name string) (kgo.Partitioner, error) {
+	switch name {
+	case Default, "":
+		return nil, nil
+	case Sticky:
+		return kgo.StickyPartitioner(), nil
+	case RoundRobin:
+		return kgo.RoundRobinPartitioner(), nil
+	case KeyHash:
+		return kgo.StickyKeyPartitioner(nil), nil
+	case LeastBackup:
+		return kgo.LeastBackupPartitioner(), nil
+	case Manual:
+		return kgo.ManualPartitioner(), nil
+	default:
+		return nil, fmt.Errorf("unknown partitioner %q", name)
+	}
+}
+
+// HashesKeys tells whether the partitioner picks the partition of keyed
+// records by hashing their key, the way the Java client's default one does.
+func HashesKeys(name string) bool {
+	return name == Default || name == "" || name == KeyHash
+}
+
+// javaPartitioner only hashes keys, so it is safe for concurrent use as long
+// as it is never given a record without key.
+var javaPartitioner = kgo.StickyKeyPartitioner(nil).ForTopic("")
+
+// JavaPartition returns the partition the Java client's default partitioner
+// writes a record with the given key to, out of n partitions.
+func JavaPartition(key []byte, n int) int32 {
+	return int32(javaPartitioner.Partition(&kgo.Record{Key: key}, n))
+}
@@ -0,0 +1,43 @@
+package producer
+
+import "testing"
+
+func TestJavaPartition(t *testing.T) {
+	// murmur2 hashes of the Java client's own tests, its default partitioner
+	// writing to the hash made positive modulo the number of partitions
+	tests := []struct {
+		key  string
+		hash int32
+	}{
+		{"21", -973932308},
+		{"foobar", -790332482},
+		{"a-little-bit-long-string", -985981536},
+		{"a-little-bit-longer-string", -1486304829},
+		{"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", -58897971},
+		{"abc", 479470107},
+	}
+	for _, tt := range tests {
+		for _, n := range []int{1, 3, 100, 1 << 20} {
+			want := int32(int(tt.hash&0x7fffffff) % n)
+			if got := JavaPartition([]byte(tt.key), n); got != want {
+				t.Errorf("key %q out of %d partitions: got %d, want %d", tt.key, n, got, want)
+			}
+		}
+	}
+
+	// one spelled out
+	if got := JavaPartition([]byte("foobar"), 100); got != 66 {
+		t.Errorf("key %q out of 100 partitions: got %d, want 66", "foobar", got)
+	}
+}
+
+func TestHashesKeys(t *testing.T) {
+	for name, want := range map[string]bool{
+		"": true, Default: true, KeyHash: true,
+		Sticky: false, RoundRobin: false, LeastBackup: false, Manual: false,
+	} {
+		if got := HashesKeys(name); got != want {
+			t.Errorf("partitioner %q: got %t, want %t", name, got, want)
+		}
+	}
+}
@@ -6,6 +6,10 @@ import (
 	"time"
 
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/plugin/kotel"
+
+	"kafka-producer-consumer-tester/internal/pkg/auth"
+	"kafka-producer-consumer-tester/internal/pkg/telemetry"
 )
 
 type Logger interface {
@@ -16,51 +20,171 @@ type Logger interface {
 }
 
 type Producer struct {
-	topic  string
-	client *kgo.Client
-	logger Logger
+	topic     string
+	partition int32 // partition every record is sent to, -1 unless the manual partitioner is used
+	client    *kgo.Client
+	telemetry *telemetry.Telemetry
+	logger    Logger
+}
+
+// Header is a record header.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Message is a record to produce.
+type Message struct {
+	Key     []byte
+	Value   []byte
+	Headers []Header
+
+	OnAck func(Ack) // called as soon as the record is acknowledged, or failed, if set
+}
+
+// Ack is where and when a produced record was written, or why it was not.
+type Ack struct {
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	Err       error
 }
 
 type ProducerConfig struct {
 	Seeds []string
 	Topic string
 	Group string
+	Auth  auth.AuthConfig
+
+	ManualPartitioning bool // records are sent to the partition they are given instead of a partitioner's pick
+
+	Partitioner string // default, sticky, round-robin, murmur2, least-backup or manual
+	Partition   int32  // partition every record is sent to with the manual partitioner
+
+	BatchMaxBytes int32         // largest batch, and so record, sent; ~1MB when 0
+	Linger        time.Duration // how long a batch waits for more records before it is sent
+	Acks          string        // all, leader or none; all when empty
+
+	Compression      string // none, gzip, snappy, lz4 or zstd; snappy when empty
+	CompressionLevel int    // level of the gzip (-2 to 9) and zstd (1 to 4) codecs, their default when 0
+
+	Tracer *kotel.Tracer // injects a traceparent header into every record when set
 }
 
 func New(cfg ProducerConfig, l Logger) (*Producer, error) {
 	l.Info("initializing producer")
 
-	cl, err := kgo.NewClient(
+	authOpts, err := auth.Opts(cfg.Auth)
+	if err != nil {
+		l.Errorf("configuring producer authentication: %v", err)
+		return nil, err
+	}
+
+	t := telemetry.New("producer")
+
+	opts := []kgo.Opt{
 		kgo.SeedBrokers(cfg.Seeds...),
+		kgo.WithHooks(t),
 		kgo.DefaultProduceTopic(cfg.Topic),
-		kgo.ProducerLinger(time.Millisecond*5), // Set the maximum delay before sending a batch, allowing more records to accumulate and enhancing batch size efficiency up to the configured batch size limit
-		kgo.ProducerBatchMaxBytes(1_000_000),   // Set max bytes of a producer batch to ~1MB (aprox. 1K at once)
-	)
+		kgo.ProducerLinger(cfg.Linger), // Set the maximum delay before sending a batch, allowing more records to accumulate and enhancing batch size efficiency up to the configured batch size limit
+	}
+	if cfg.BatchMaxBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(cfg.BatchMaxBytes))
+	} else {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(1_000_000)) // Set max bytes of a producer batch to ~1MB (aprox. 1K at once)
+	}
+	opts = append(opts, authOpts...)
+	if cfg.Tracer != nil {
+		opts = append(opts, kgo.WithHooks(kotel.NewKotel(kotel.WithTracer(cfg.Tracer)).Hooks()...))
+	}
+	c, err := compression(cfg.Compression, cfg.CompressionLevel)
+	if err != nil {
+		l.Errorf("configuring producer compression: %v", err)
+		return nil, err
+	}
+	opts = append(opts, kgo.ProducerBatchCompression(c))
+	ackOpts, err := acks(cfg.Acks)
+	if err != nil {
+		l.Errorf("configuring producer acks: %v", err)
+		return nil, err
+	}
+	opts = append(opts, ackOpts...)
+
+	partition := int32(-1)
+	if cfg.ManualPartitioning {
+		opts = append(opts, kgo.RecordPartitioner(kgo.ManualPartitioner()))
+	} else {
+		pt, err := partitioner(cfg.Partitioner)
+		if err != nil {
+			l.Errorf("configuring producer partitioner: %v", err)
+			return nil, err
+		}
+		if pt != nil {
+			opts = append(opts, kgo.RecordPartitioner(pt))
+		}
+		if cfg.Partitioner == Manual {
+			partition = cfg.Partition
+		}
+	}
+
+	cl, err := kgo.NewClient(opts...)
 	if err != nil {
 		l.Errorf("creating producer client: %v", err)
 		return nil, err
 	}
 	if err := cl.Ping(context.Background()); err != nil {
 		l.Errorf("verifying producer client connection: %v", err)
+		cl.Close()
 		return nil, err
 	}
 
-	return &Producer{client: cl, topic: cfg.Topic, logger: l}, nil
+	return &Producer{client: cl, topic: cfg.Topic, partition: partition, telemetry: t, logger: l}, nil
 }
 
-func (p *Producer) ProduceBatch(ctx context.Context, payloads [][]byte) error {
+// ProduceBatch sends the messages and waits for all of them to be
+// acknowledged, returning an ack per message, in order, along with the first
+// error. The acks of the messages that failed carry their error.
+func (p *Producer) ProduceBatch(ctx context.Context, msgs []Message) ([]Ack, error) {
 	var records []*kgo.Record
 
-	for _, payload := range payloads {
-		records = append(records, &kgo.Record{Value: payload})
+	for _, msg := range msgs {
+		r := &kgo.Record{Key: msg.Key, Value: msg.Value, Partition: p.partition}
+		for _, h := range msg.Headers {
+			r.Headers = append(r.Headers, kgo.RecordHeader{Key: h.Key, Value: h.Value})
+		}
+		records = append(records, r)
 	}
 
-	err := p.client.ProduceSync(ctx, records...).FirstErr()
-	return err
+	// records are acknowledged in another order across partitions, so every
+	// ack is kept at the index of its message
+	acks := make([]Ack, len(records))
+	wg := sync.WaitGroup{}
+	wg.Add(len(records))
+
+	for i, r := range records {
+		p.client.Produce(ctx, r, func(r *kgo.Record, err error) {
+			defer wg.Done()
+
+			acks[i] = Ack{Partition: r.Partition, Offset: r.Offset, Timestamp: r.Timestamp, Err: err}
+			if msgs[i].OnAck != nil {
+				msgs[i].OnAck(acks[i])
+			}
+		})
+	}
+
+	wg.Wait()
+
+	for _, ack := range acks {
+		if ack.Err != nil {
+			return acks, ack.Err
+		}
+	}
+
+	return acks, nil
 }
 
 func (p *Producer) Produce(ctx context.Context, payload []byte) (err error) {
-	record := &kgo.Record{Value: payload}
+	record := &kgo.Record{Value: payload, Partition: p.partition}
 	wg := sync.WaitGroup{}
 
 	wg.Add(1)
@@ -73,6 +197,17 @@ func (p *Producer) Produce(ctx context.Context, payload []byte) (err error) {
 	return
 }
 
+// ProduceTo sends a single record to the given partition. It requires the
+// producer to be created with manual partitioning.
+func (p *Producer) ProduceTo(ctx context.Context, partition int32, payload []byte) error {
+	return p.client.ProduceSync(ctx, &kgo.Record{Value: payload, Partition: partition}).FirstErr()
+}
+
+// BrokerStats returns what the producer client observed of every broker.
+func (p *Producer) BrokerStats() []telemetry.BrokerStats {
+	return p.telemetry.Snapshot()
+}
+
 func (p *Producer) Shutdown() {
 	p.logger.Info("closing producer")
 	p.client.Close()
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+)
+
+// Codec frames the values encoded by another codec in the Confluent wire
+// format, registering its schema under the subject when created. Decoding
+// fails on values whose schema is not of the type of the codec.
+type Codec struct {
+	codec    codec.SchemaCodec
+	client   *Client
+	typ      string
+	id       int
+	protobuf bool
+}
+
+// NewCodec registers the schema of c under the subject and returns a codec
+// framing its values.
+func NewCodec(ctx context.Context, c codec.Codec, client *Client, subject string) (*Codec, error) {
+	sc, ok := c.(codec.SchemaCodec)
+	if !ok {
+		return nil, fmt.Errorf("the %s codec has no schema to register", c.Name())
+	}
+
+	typ, schema := sc.Schema()
+	s := Schema{Schema: schema, Type: typ}
+	if typ == codec.SchemaAvro {
+		s.Type = "" // the registry default
+	}
+
+	id, err := client.Register(ctx, subject, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Codec{codec: sc, client: client, typ: s.Type, id: id, protobuf: typ == codec.SchemaProtobuf}, nil
+}
+
+func (c *Codec) Name() string { return c.codec.Name() + "+registry" }
+
+// ID returns the ID the schema was registered with.
+func (c *Codec) ID() int { return c.id }
+
+func (c *Codec) Encode(e codec.Event) ([]byte, error) {
+	b, err := c.codec.Encode(e)
+	if err != nil {
+		return nil, err
+	}
+
+	return frame(c.id, c.protobuf, b), nil
+}
+
+func (c *Codec) Decode(b []byte) (codec.Event, error) {
+	id, payload, err := unframe(b, c.protobuf)
+	if err != nil {
+		return codec.Event{}, err
+	}
+
+	if id != c.id {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		s, err := c.client.SchemaByID(ctx, id)
+		if err != nil {
+			return codec.Event{}, err
+		}
+		if s.Type != c.typ {
+			return codec.Event{}, fmt.Errorf("schema %d is not a %s schema", id, c.codec.Name())
+		}
+	}
+
+	return c.codec.Decode(payload)
+}
@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Fake is an in-process stand-in for a Schema Registry, implementing the
+// subset of the API the client uses. Schemas are kept in memory and never
+// checked for compatibility.
+type Fake struct {
+	mu       sync.Mutex
+	schemas  []Schema         // indexed by ID - 1
+	subjects map[string][]int // IDs of every version of a subject
+
+	server *http.Server
+	addr   string
+}
+
+func NewFake() *Fake {
+	return &Fake{subjects: map[string][]int{}}
+}
+
+// Start serves the fake registry on a random local port, returning its URL.
+func (f *Fake) Start() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /subjects/{subject}/versions", f.register)
+	mux.HandleFunc("GET /schemas/ids/{id}", f.schemaByID)
+
+	f.server = &http.Server{Handler: mux}
+	f.addr = "http://" + l.Addr().String()
+
+	go f.server.Serve(l)
+
+	return f.addr, nil
+}
+
+func (f *Fake) Shutdown() {
+	if f.server != nil {
+		f.server.Close()
+	}
+}
+
+func (f *Fake) register(w http.ResponseWriter, r *http.Request) {
+	var s Schema
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil || s.Schema == "" {
+		writeError(w, http.StatusUnprocessableEntity, 42201, "invalid schema")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := 0
+	for i, known := range f.schemas {
+		if known == s {
+			id = i + 1
+			break
+		}
+	}
+	if id == 0 {
+		f.schemas = append(f.schemas, s)
+		id = len(f.schemas)
+	}
+
+	subject := r.PathValue("subject")
+	registered := false
+	for _, v := range f.subjects[subject] {
+		registered = registered || v == id
+	}
+	if !registered {
+		f.subjects[subject] = append(f.subjects[subject], id)
+	}
+
+	writeJSON(w, map[string]int{"id": id})
+}
+
+func (f *Fake) schemaByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err != nil || id < 1 || id > len(f.schemas) {
+		writeError(w, http.StatusNotFound, 40403, "schema "+r.PathValue("id")+" not found")
+		return
+	}
+
+	writeJSON(w, f.schemas[id-1])
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", contentType)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status, code int, msg string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiError{Code: code, Message: msg})
+}
@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// Schema is a schema held by the registry. An empty type stands for Avro, as
+// in the registry API.
+type Schema struct {
+	Schema string `json:"schema"`
+	Type   string `json:"schemaType,omitempty"`
+}
+
+type RegistryConfig struct {
+	URL      string
+	User     string // basic auth credentials, none when empty
+	Password string
+}
+
+// Client talks to a Confluent compatible Schema Registry. Schemas looked up by
+// ID are cached since they never change.
+type Client struct {
+	cfg  RegistryConfig
+	http *http.Client
+
+	mu    sync.Mutex
+	cache map[int]Schema
+}
+
+func New(cfg RegistryConfig) *Client {
+	return &Client{cfg: cfg, http: &http.Client{Timeout: 10 * time.Second}, cache: map[int]Schema{}}
+}
+
+// Register registers the schema under the subject, returning its ID. It is
+// idempotent: the ID of an already registered schema is returned as is.
+func (c *Client) Register(ctx context.Context, subject string, s Schema) (int, error) {
+	var res struct {
+		ID int `json:"id"`
+	}
+
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := c.do(ctx, http.MethodPost, path, s, &res); err != nil {
+		return 0, fmt.Errorf("registering schema under %s: %w", subject, err)
+	}
+
+	c.mu.Lock()
+	c.cache[res.ID] = s
+	c.mu.Unlock()
+
+	return res.ID, nil
+}
+
+// SchemaByID looks up the schema with the given ID.
+func (c *Client) SchemaByID(ctx context.Context, id int) (Schema, error) {
+	c.mu.Lock()
+	s, ok := c.cache[id]
+	c.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &s); err != nil {
+		return s, fmt.Errorf("looking up schema %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.cache[id] = s
+	c.mu.Unlock()
+
+	return s, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.URL+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.cfg.User != "" {
+		req.SetBasicAuth(c.cfg.User, c.cfg.Password)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var e apiError
+		if err := json.NewDecoder(res.Body).Decode(&e); err != nil || e.Message == "" {
+			return fmt.Errorf("unexpected status %s", res.Status)
+		}
+		return fmt.Errorf("%s (error code %d)", e.Message, e.Code)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// apiError is the error body returned by the registry.
+type apiError struct {
+	Code    int    `json:"error_code"`
+	Message string `json:"message"`
+}
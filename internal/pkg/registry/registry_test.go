@@ -0,0 +1,232 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"kafka-producer-consumer-tester/internal/pkg/codec"
+)
+
+func startFake(t *testing.T) *Client {
+	t.Helper()
+
+	f := NewFake()
+	url, err := f.Start()
+	if err != nil {
+		t.Fatalf("starting the fake registry: %v", err)
+	}
+	t.Cleanup(f.Shutdown)
+
+	return New(RegistryConfig{URL: url})
+}
+
+func TestClientRegister(t *testing.T) {
+	ctx := context.Background()
+	c := startFake(t)
+
+	avroSchema := Schema{Schema: `{"type": "string"}`}
+	protoSchema := Schema{Schema: `syntax = "proto3"; message M {}`, Type: codec.SchemaProtobuf}
+
+	tests := []struct {
+		subject string
+		schema  Schema
+		id      int
+	}{
+		{"a-value", avroSchema, 1},
+		{"a-value", avroSchema, 1}, // registering again is idempotent
+		{"b-value", avroSchema, 1}, // the same schema keeps its ID under another subject
+		{"b-value", protoSchema, 2},
+	}
+	for _, tt := range tests {
+		id, err := c.Register(ctx, tt.subject, tt.schema)
+		if err != nil {
+			t.Fatalf("registering under %s: %v", tt.subject, err)
+		}
+		if id != tt.id {
+			t.Errorf("registering %q under %s: got ID %d, want %d", tt.schema.Schema, tt.subject, id, tt.id)
+		}
+	}
+
+	if _, err := c.Register(ctx, "c-value", Schema{}); err == nil {
+		t.Error("registering an empty schema: got no error")
+	}
+}
+
+func TestClientSchemaByID(t *testing.T) {
+	ctx := context.Background()
+	c := startFake(t)
+
+	want := Schema{Schema: `syntax = "proto3"; message M {}`, Type: codec.SchemaProtobuf}
+	id, err := c.Register(ctx, "a-value", want)
+	if err != nil {
+		t.Fatalf("registering: %v", err)
+	}
+
+	// a new client has nothing cached, so the schema comes from the registry
+	fresh := New(c.cfg)
+
+	for _, cl := range []*Client{c, fresh} {
+		got, err := cl.SchemaByID(ctx, id)
+		if err != nil {
+			t.Fatalf("looking up schema %d: %v", id, err)
+		}
+		if got != want {
+			t.Errorf("schema %d: got %+v, want %+v", id, got, want)
+		}
+	}
+
+	if _, err := fresh.SchemaByID(ctx, id+1); err == nil || !strings.Contains(err.Error(), "error code 40403") {
+		t.Errorf("looking up an unknown schema: got %v, want error code 40403", err)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	payload := []byte("payload")
+
+	tests := []struct {
+		name     string
+		id       int
+		protobuf bool
+		want     []byte
+	}{
+		{"avro", 1, false, append([]byte{0, 0, 0, 0, 1}, payload...)},
+		{"large id", 0x01020304, false, append([]byte{0, 1, 2, 3, 4}, payload...)},
+		{"protobuf", 7, true, append([]byte{0, 0, 0, 0, 7, 0}, payload...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := frame(tt.id, tt.protobuf, payload)
+			if !bytes.Equal(b, tt.want) {
+				t.Fatalf("got % x, want % x", b, tt.want)
+			}
+
+			id, got, err := unframe(b, tt.protobuf)
+			if err != nil {
+				t.Fatalf("unframing: %v", err)
+			}
+			if id != tt.id || !bytes.Equal(got, payload) {
+				t.Errorf("unframed ID %d and payload %q, want %d and %q", id, got, tt.id, payload)
+			}
+		})
+	}
+}
+
+func TestUnframeMessageIndexes(t *testing.T) {
+	payload := []byte("payload")
+
+	withIndexes := func(indexes ...int64) []byte {
+		b := []byte{magicByte, 0, 0, 0, 9}
+		b = binary.AppendVarint(b, int64(len(indexes)))
+		for _, i := range indexes {
+			b = binary.AppendVarint(b, i)
+		}
+		return append(b, payload...)
+	}
+
+	tests := []struct {
+		name    string
+		b       []byte
+		wantErr bool
+	}{
+		{"first message", withIndexes(), false},
+		{"nested message", withIndexes(1, 0, 2), false},
+		{"negative count", append([]byte{magicByte, 0, 0, 0, 9}, binary.AppendVarint(nil, -1)...), true},
+		{"truncated indexes", []byte{magicByte, 0, 0, 0, 9, 4, 2}, true},
+		{"no indexes", []byte{magicByte, 0, 0, 0, 9}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, got, err := unframe(tt.b, true)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got ID %d and payload %q, want an error", id, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unframing: %v", err)
+			}
+			if id != 9 || !bytes.Equal(got, payload) {
+				t.Errorf("unframed ID %d and payload %q, want 9 and %q", id, got, payload)
+			}
+		})
+	}
+}
+
+func TestUnframeNotFramed(t *testing.T) {
+	for _, b := range [][]byte{nil, {0, 0, 0}, {1, 0, 0, 0, 1, 'x'}, []byte(`{"ID":"x"}`)} {
+		if _, _, err := unframe(b, false); !errors.Is(err, errNotFramed) {
+			t.Errorf("unframing % x: got %v, want %v", b, err, errNotFramed)
+		}
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	c := startFake(t)
+
+	e := codec.Event{ID: "id", State: "success", SentAt: 42}
+
+	for _, name := range []string{codec.JSON, codec.Avro, codec.Protobuf} {
+		t.Run(name, func(t *testing.T) {
+			inner, err := codec.New(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rc, err := NewCodec(ctx, inner, c, name+"-value")
+			if err != nil {
+				t.Fatalf("creating the codec: %v", err)
+			}
+
+			b, err := rc.Encode(e)
+			if err != nil {
+				t.Fatalf("encoding: %v", err)
+			}
+			if id, _, err := unframe(b, name == codec.Protobuf); err != nil || id != rc.ID() {
+				t.Fatalf("value framed with ID %d (%v), want %d", id, err, rc.ID())
+			}
+
+			got, err := rc.Decode(b)
+			if err != nil {
+				t.Fatalf("decoding: %v", err)
+			}
+			if got != e {
+				t.Errorf("got %+v, want %+v", got, e)
+			}
+		})
+	}
+
+	inner, _ := codec.New(codec.MessagePack)
+	if _, err := NewCodec(ctx, inner, c, "msgpack-value"); err == nil {
+		t.Error("creating a codec without schema: got no error")
+	}
+}
+
+func TestCodecRejectsOtherSchemaTypes(t *testing.T) {
+	ctx := context.Background()
+	c := startFake(t)
+
+	avroCodec, _ := codec.New(codec.Avro)
+	jsonCodec, _ := codec.New(codec.JSON)
+
+	a, err := NewCodec(ctx, avroCodec, c, "a-value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := NewCodec(ctx, jsonCodec, c, "j-value")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := j.Encode(codec.Event{ID: "id", State: "success"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Decode(b); err == nil {
+		t.Error("decoding a JSON schema value with the Avro codec: got no error")
+	}
+}
@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// magicByte starts every value in the Confluent wire format. It is followed
+// by the big endian schema ID and, for Protobuf, the index of the message in
+// the schema.
+const magicByte = 0
+
+var errNotFramed = errors.New("value is not in the Confluent wire format")
+
+// frame prefixes the payload with the schema ID. Protobuf payloads are given
+// the index of the first message of the schema, written as a single 0.
+func frame(id int, protobuf bool, payload []byte) []byte {
+	b := make([]byte, 0, 6+len(payload))
+	b = append(b, magicByte)
+	b = binary.BigEndian.AppendUint32(b, uint32(id))
+	if protobuf {
+		b = binary.AppendVarint(b, 0)
+	}
+
+	return append(b, payload...)
+}
+
+// unframe returns the schema ID and payload of a value. The message indexes
+// of Protobuf values are skipped.
+func unframe(b []byte, protobuf bool) (int, []byte, error) {
+	if len(b) < 5 || b[0] != magicByte {
+		return 0, nil, errNotFramed
+	}
+
+	id := int(binary.BigEndian.Uint32(b[1:5]))
+	b = b[5:]
+
+	if protobuf {
+		count, n := binary.Varint(b)
+		if n <= 0 || count < 0 {
+			return 0, nil, fmt.Errorf("invalid message indexes")
+		}
+		b = b[n:]
+
+		for i := int64(0); i < count; i++ {
+			if _, n = binary.Varint(b); n <= 0 {
+				return 0, nil, fmt.Errorf("invalid message indexes")
+			}
+			b = b[n:]
+		}
+	}
+
+	return id, b, nil
+}
@@ -0,0 +1,119 @@
+package stats
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	minBound = 100 * time.Microsecond
+	growth   = 1.1
+	buckets  = 200 // covers latencies up to ~5 hours
+)
+
+// Histogram records durations into exponentially sized buckets, each 10%
+// larger than the previous, so quantiles are accurate to within 10%.
+type Histogram struct {
+	mu sync.Mutex
+
+	counts []int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// Summary is a point in time view of a histogram.
+type Summary struct {
+	Count int64         `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{counts: make([]int64, buckets)}
+}
+
+func (h *Histogram) Observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[bucketOf(d)]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// Quantile returns the upper bound of the bucket holding the q-th quantile.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.quantile(q)
+}
+
+func (h *Histogram) Summary() Summary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := Summary{Count: h.count, Max: h.max}
+	if h.count > 0 {
+		s.Mean = h.sum / time.Duration(h.count)
+		s.P50 = h.quantile(0.5)
+		s.P90 = h.quantile(0.9)
+		s.P99 = h.quantile(0.99)
+	}
+
+	return s
+}
+
+func (h *Histogram) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts = make([]int64, buckets)
+	h.count, h.sum, h.max = 0, 0, 0
+}
+
+func (h *Histogram) quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(q * float64(h.count)))
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			if bound := UpperBound(i); bound < h.max {
+				return bound
+			}
+			return h.max
+		}
+	}
+
+	return h.max
+}
+
+// UpperBound returns the largest duration falling into the i-th bucket.
+func UpperBound(i int) time.Duration {
+	return time.Duration(float64(minBound) * math.Pow(growth, float64(i)))
+}
+
+func bucketOf(d time.Duration) int {
+	if d <= minBound {
+		return 0
+	}
+
+	i := int(math.Ceil(math.Log(float64(d)/float64(minBound)) / math.Log(growth)))
+	if i >= buckets {
+		return buckets - 1
+	}
+
+	return i
+}
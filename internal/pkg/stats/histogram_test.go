@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuantile(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+
+	// the upper bound of a bucket is at most 10% above the quantile
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.01, 10 * time.Millisecond},
+		{0.5, 500 * time.Millisecond},
+		{0.9, 900 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+		{0.999, 999 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		got := h.Quantile(tt.q)
+		if got < tt.want || float64(got) > float64(tt.want)*growth {
+			t.Errorf("quantile %v: got %s, want %s to %s", tt.q, got, tt.want, time.Duration(float64(tt.want)*growth))
+		}
+	}
+
+	// never above the largest duration observed
+	if got := h.Quantile(1); got != time.Second {
+		t.Errorf("quantile 1: got %s, want %s", got, time.Second)
+	}
+}
+
+func TestQuantileBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want time.Duration
+	}{
+		{"zero", 0, 0},
+		{"below the first bucket", time.Microsecond, time.Microsecond},
+		{"first bucket", minBound, minBound},
+		{"beyond the last bucket", 100 * time.Hour, UpperBound(buckets - 1)},
+	}
+	for _, tt := range tests {
+		h := NewHistogram()
+		h.Observe(tt.d)
+
+		if got := h.Quantile(0.5); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBucketOf(t *testing.T) {
+	for i := 0; i < buckets; i++ {
+		bound := UpperBound(i)
+		if got := bucketOf(bound); got != i {
+			t.Errorf("upper bound of bucket %d, %s, falls into bucket %d", i, bound, got)
+		}
+		if i > 0 {
+			if got := bucketOf(UpperBound(i-1) + 1); got != i {
+				t.Errorf("%s falls into bucket %d, want %d", UpperBound(i-1)+1, got, i)
+			}
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	h := NewHistogram()
+	if s := h.Summary(); s != (Summary{}) {
+		t.Errorf("summary of an empty histogram: got %+v", s)
+	}
+
+	for _, d := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 6 * time.Millisecond} {
+		h.Observe(d)
+	}
+
+	s := h.Summary()
+	if s.Count != 3 || s.Mean != 3*time.Millisecond || s.Max != 6*time.Millisecond {
+		t.Errorf("got count %d, mean %s and max %s, want 3, 3ms and 6ms", s.Count, s.Mean, s.Max)
+	}
+	if !(s.P50 <= s.P90 && s.P90 <= s.P99 && s.P99 <= s.Max) {
+		t.Errorf("quantiles out of order: %+v", s)
+	}
+
+	h.Reset()
+	if s := h.Summary(); s != (Summary{}) {
+		t.Errorf("summary after a reset: got %+v", s)
+	}
+}
@@ -0,0 +1,217 @@
+package telemetry
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+)
+
+// BrokerStats aggregates what a client observed of a single broker. Write
+// latency is spent on the client queueing and writing requests, while response
+// wait is the time between the request being written and the response being
+// available, which is dominated by the broker.
+type BrokerStats struct {
+	Client string `json:"client"`
+	Broker string `json:"broker"`
+
+	Connects      int64 `json:"connects"`
+	ConnectErrors int64 `json:"connect_errors"`
+	Disconnects   int64 `json:"disconnects"`
+
+	WriteLatency stats.Summary `json:"write_latency"`
+	ResponseWait stats.Summary `json:"response_wait"`
+	ReadLatency  stats.Summary `json:"read_latency"`
+
+	Throttles    int64         `json:"throttles"`
+	ThrottleTime time.Duration `json:"throttle_time"`
+
+	ProducedBatches      int64   `json:"produced_batches"`
+	ProducedRecords      int64   `json:"produced_records"`
+	ProducedBytes        int64   `json:"produced_bytes"`
+	ProduceCompression   float64 `json:"produce_compression_ratio"`
+	FetchedBatches       int64   `json:"fetched_batches"`
+	FetchedRecords       int64   `json:"fetched_records"`
+	FetchedBytes         int64   `json:"fetched_bytes"`
+	FetchCompression     float64 `json:"fetch_compression_ratio"`
+	AverageProducedBatch float64 `json:"average_produced_batch"`
+	AverageFetchedBatch  float64 `json:"average_fetched_batch"`
+}
+
+type broker struct {
+	connects      int64
+	connectErrors int64
+	disconnects   int64
+
+	write *stats.Histogram
+	wait  *stats.Histogram
+	read  *stats.Histogram
+
+	throttles    int64
+	throttleTime time.Duration
+
+	producedBatches      int64
+	producedRecords      int64
+	producedUncompressed int64
+	producedCompressed   int64
+
+	fetchedBatches      int64
+	fetchedRecords      int64
+	fetchedUncompressed int64
+	fetchedCompressed   int64
+}
+
+// Telemetry implements the kgo hooks and aggregates them per broker.
+type Telemetry struct {
+	client string
+
+	mu      sync.Mutex
+	brokers map[string]*broker
+}
+
+var (
+	_ kgo.HookBrokerConnect       = (*Telemetry)(nil)
+	_ kgo.HookBrokerDisconnect    = (*Telemetry)(nil)
+	_ kgo.HookBrokerWrite         = (*Telemetry)(nil)
+	_ kgo.HookBrokerRead          = (*Telemetry)(nil)
+	_ kgo.HookBrokerThrottle      = (*Telemetry)(nil)
+	_ kgo.HookProduceBatchWritten = (*Telemetry)(nil)
+	_ kgo.HookFetchBatchRead      = (*Telemetry)(nil)
+)
+
+// New returns the telemetry of a client, the name telling clients apart.
+func New(client string) *Telemetry {
+	return &Telemetry{client: client, brokers: map[string]*broker{}}
+}
+
+func (t *Telemetry) broker(meta kgo.BrokerMetadata) *broker {
+	name := kgo.NodeName(meta.NodeID)
+
+	b, ok := t.brokers[name]
+	if !ok {
+		b = &broker{write: stats.NewHistogram(), wait: stats.NewHistogram(), read: stats.NewHistogram()}
+		t.brokers[name] = b
+	}
+
+	return b
+}
+
+func (t *Telemetry) OnBrokerConnect(meta kgo.BrokerMetadata, _ time.Duration, _ net.Conn, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.broker(meta)
+	b.connects++
+	if err != nil {
+		b.connectErrors++
+	}
+}
+
+func (t *Telemetry) OnBrokerDisconnect(meta kgo.BrokerMetadata, _ net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.broker(meta).disconnects++
+}
+
+func (t *Telemetry) OnBrokerWrite(meta kgo.BrokerMetadata, _ int16, _ int, writeWait, timeToWrite time.Duration, _ error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.broker(meta).write.Observe(writeWait + timeToWrite)
+}
+
+func (t *Telemetry) OnBrokerRead(meta kgo.BrokerMetadata, _ int16, _ int, readWait, timeToRead time.Duration, _ error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.broker(meta)
+	b.wait.Observe(readWait)
+	b.read.Observe(timeToRead)
+}
+
+func (t *Telemetry) OnBrokerThrottle(meta kgo.BrokerMetadata, interval time.Duration, _ bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.broker(meta)
+	b.throttles++
+	b.throttleTime += interval
+}
+
+func (t *Telemetry) OnProduceBatchWritten(meta kgo.BrokerMetadata, _ string, _ int32, m kgo.ProduceBatchMetrics) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.broker(meta)
+	b.producedBatches++
+	b.producedRecords += int64(m.NumRecords)
+	b.producedUncompressed += int64(m.UncompressedBytes)
+	b.producedCompressed += int64(m.CompressedBytes)
+}
+
+func (t *Telemetry) OnFetchBatchRead(meta kgo.BrokerMetadata, _ string, _ int32, m kgo.FetchBatchMetrics) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.broker(meta)
+	b.fetchedBatches++
+	b.fetchedRecords += int64(m.NumRecords)
+	b.fetchedUncompressed += int64(m.UncompressedBytes)
+	b.fetchedCompressed += int64(m.CompressedBytes)
+}
+
+// Snapshot returns the current stats of every broker, sorted by broker.
+func (t *Telemetry) Snapshot() []BrokerStats {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := make([]BrokerStats, 0, len(t.brokers))
+	for name, b := range t.brokers {
+		snapshot = append(snapshot, BrokerStats{
+			Client: t.client,
+			Broker: name,
+
+			Connects:      b.connects,
+			ConnectErrors: b.connectErrors,
+			Disconnects:   b.disconnects,
+
+			WriteLatency: b.write.Summary(),
+			ResponseWait: b.wait.Summary(),
+			ReadLatency:  b.read.Summary(),
+
+			Throttles:    b.throttles,
+			ThrottleTime: b.throttleTime,
+
+			ProducedBatches:      b.producedBatches,
+			ProducedRecords:      b.producedRecords,
+			ProducedBytes:        b.producedCompressed,
+			ProduceCompression:   ratio(b.producedUncompressed, b.producedCompressed),
+			FetchedBatches:       b.fetchedBatches,
+			FetchedRecords:       b.fetchedRecords,
+			FetchedBytes:         b.fetchedCompressed,
+			FetchCompression:     ratio(b.fetchedUncompressed, b.fetchedCompressed),
+			AverageProducedBatch: ratio(b.producedRecords, b.producedBatches),
+			AverageFetchedBatch:  ratio(b.fetchedRecords, b.fetchedBatches),
+		})
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Broker < snapshot[j].Broker })
+
+	return snapshot
+}
+
+func ratio(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
@@ -0,0 +1,122 @@
+package telemetry
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"kafka-producer-consumer-tester/internal/pkg/stats"
+)
+
+var (
+	broker0 = kgo.BrokerMetadata{NodeID: 0}
+	broker1 = kgo.BrokerMetadata{NodeID: 1}
+	seed    = kgo.BrokerMetadata{NodeID: math.MinInt32} // the first seed broker, before the metadata is known
+)
+
+func summary(ds ...time.Duration) stats.Summary {
+	h := stats.NewHistogram()
+	for _, d := range ds {
+		h.Observe(d)
+	}
+	return h.Summary()
+}
+
+// empty returns the stats of a broker only seen by the hooks of the test.
+func empty(broker string) BrokerStats {
+	return BrokerStats{Client: "producer", Broker: broker, WriteLatency: summary(), ResponseWait: summary(), ReadLatency: summary()}
+}
+
+func with(s BrokerStats, change func(*BrokerStats)) BrokerStats {
+	change(&s)
+	return s
+}
+
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		name  string
+		hooks func(*Telemetry)
+		want  []BrokerStats
+	}{
+		{name: "nothing observed", hooks: func(*Telemetry) {}, want: []BrokerStats{}},
+		{
+			name: "connections",
+			hooks: func(t *Telemetry) {
+				t.OnBrokerConnect(broker0, 0, nil, nil)
+				t.OnBrokerConnect(broker0, 0, nil, errors.New("refused"))
+				t.OnBrokerDisconnect(broker0, nil)
+			},
+			want: []BrokerStats{with(empty("0"), func(s *BrokerStats) { s.Connects, s.ConnectErrors, s.Disconnects = 2, 1, 1 })},
+		},
+		{
+			name: "latencies",
+			hooks: func(t *Telemetry) {
+				t.OnBrokerWrite(broker0, 0, 0, time.Millisecond, 2*time.Millisecond, nil)
+				t.OnBrokerWrite(broker0, 0, 0, 0, 5*time.Millisecond, nil)
+				t.OnBrokerRead(broker0, 0, 0, 10*time.Millisecond, time.Millisecond, nil)
+			},
+			want: []BrokerStats{with(empty("0"), func(s *BrokerStats) {
+				// the write latency covers the wait in the queue
+				s.WriteLatency = summary(3*time.Millisecond, 5*time.Millisecond)
+				s.ResponseWait = summary(10 * time.Millisecond)
+				s.ReadLatency = summary(time.Millisecond)
+			})},
+		},
+		{
+			name: "throttling",
+			hooks: func(t *Telemetry) {
+				t.OnBrokerThrottle(broker0, 100*time.Millisecond, false)
+				t.OnBrokerThrottle(broker0, 50*time.Millisecond, true)
+			},
+			want: []BrokerStats{with(empty("0"), func(s *BrokerStats) { s.Throttles, s.ThrottleTime = 2, 150*time.Millisecond })},
+		},
+		{
+			name: "compression",
+			hooks: func(t *Telemetry) {
+				t.OnProduceBatchWritten(broker0, "topic", 0, kgo.ProduceBatchMetrics{NumRecords: 10, UncompressedBytes: 1000, CompressedBytes: 200})
+				t.OnProduceBatchWritten(broker0, "topic", 1, kgo.ProduceBatchMetrics{NumRecords: 30, UncompressedBytes: 3000, CompressedBytes: 300})
+				t.OnFetchBatchRead(broker0, "topic", 0, kgo.FetchBatchMetrics{NumRecords: 40, UncompressedBytes: 4000, CompressedBytes: 4000})
+			},
+			want: []BrokerStats{with(empty("0"), func(s *BrokerStats) {
+				s.ProducedBatches, s.ProducedRecords, s.ProducedBytes = 2, 40, 500
+				s.ProduceCompression, s.AverageProducedBatch = 8, 20
+				s.FetchedBatches, s.FetchedRecords, s.FetchedBytes = 1, 40, 4000
+				s.FetchCompression, s.AverageFetchedBatch = 1, 40
+			})},
+		},
+		{
+			name: "sorted by broker",
+			hooks: func(t *Telemetry) {
+				t.OnBrokerThrottle(broker1, time.Second, false)
+				t.OnBrokerConnect(seed, 0, nil, nil)
+				t.OnBrokerThrottle(broker0, time.Millisecond, false)
+			},
+			want: []BrokerStats{
+				with(empty("0"), func(s *BrokerStats) { s.Throttles, s.ThrottleTime = 1, time.Millisecond }),
+				with(empty("1"), func(s *BrokerStats) { s.Throttles, s.ThrottleTime = 1, time.Second }),
+				with(empty("seed_0"), func(s *BrokerStats) { s.Connects = 1 }),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tel := New("producer")
+			tt.hooks(tel)
+
+			if got := tel.Snapshot(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotOfNoTelemetry(t *testing.T) {
+	var tel *Telemetry
+	if got := tel.Snapshot(); got != nil {
+		t.Errorf("got %+v, want nil", got)
+	}
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/twmb/franz-go/plugin/kotel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	None = "none"
+	OTLP = "otlp"
+	File = "file"
+)
+
+type Logger interface {
+	Info(string)
+	Infof(string, ...any)
+	Error(string)
+	Errorf(string, ...any)
+}
+
+// Tracing owns the OpenTelemetry tracer provider the Kafka clients report their
+// spans to. Trace context travels between them in W3C traceparent headers.
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+	file     *os.File
+	logger   Logger
+}
+
+type TracingConfig struct {
+	Exporter    string  // none, otlp or file
+	File        string  // destination of the file exporter
+	ServiceName string  // service.name resource attribute
+	SampleRatio float64 // share of traces sampled, traceparent is injected either way
+}
+
+// New sets up the exporter. The OTLP exporter is configured through the
+// standard OTEL_EXPORTER_OTLP_* environment variables.
+func New(cfg TracingConfig, l Logger) (*Tracing, error) {
+	t := &Tracing{logger: l}
+
+	var exporter sdktrace.SpanExporter
+
+	switch cfg.Exporter {
+	case None, "":
+		return t, nil
+	case OTLP:
+		exp, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			l.Errorf("creating OTLP trace exporter: %v", err)
+			return nil, err
+		}
+		exporter = exp
+	case File:
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			l.Errorf("opening trace file: %v", err)
+			return nil, err
+		}
+
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			l.Errorf("creating file trace exporter: %v", err)
+			return nil, err
+		}
+		exporter, t.file = exp, f
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+
+	l.Infof("exporting traces to %s", cfg.Exporter)
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+
+	return t, nil
+}
+
+// Tracer returns a kotel tracer creating publish, receive and process spans,
+// or nil when tracing is disabled.
+func (t *Tracing) Tracer(opts ...kotel.TracerOpt) *kotel.Tracer {
+	if t.provider == nil {
+		return nil
+	}
+
+	opts = append(opts, kotel.TracerProvider(t.provider), kotel.TracerPropagator(propagation.TraceContext{}))
+	return kotel.NewTracer(opts...)
+}
+
+func (t *Tracing) Shutdown() {
+	if t.provider == nil {
+		return
+	}
+
+	t.logger.Info("flushing traces")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := t.provider.Shutdown(ctx); err != nil {
+		t.logger.Errorf("shutting down tracer provider: %v", err)
+	}
+	if t.file != nil {
+		t.file.Close()
+	}
+}